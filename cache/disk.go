@@ -0,0 +1,377 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jean-jacket/grss/utils"
+)
+
+const (
+	// diskCompactInterval is how often expired entries are removed and the
+	// size cap is enforced
+	diskCompactInterval = 5 * time.Minute
+
+	// diskTempPrefix marks entries being written, left behind by crashes
+	diskTempPrefix = ".tmp-"
+
+	// diskTouchInterval is how stale the use time of an entry gets before a
+	// hit updates it, to save a metadata write on most hits
+	diskTouchInterval = time.Minute
+)
+
+// DiskCache is a cache implementation that stores each entry in a file,
+// so that the cache survives restarts. Files are named after a hash of the
+// key and written to a temporary file first, then renamed into place, so a
+// crash never leaves a partial entry. Expired entries are removed by
+// periodic compaction, which also evicts the least recently used entries
+// when the cache exceeds its size.
+type DiskCache struct {
+	dir      string
+	maxBytes int64 // 0 for no limit
+
+	mu      sync.Mutex // Guards size and serializes writes with removals
+	size    int64
+	compact chan struct{}
+}
+
+// NewDiskCache creates a disk cache in dir holding at most maxBytes bytes
+// of entries. Zero disables the limit.
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	dc := &DiskCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		compact:  make(chan struct{}, 1),
+	}
+
+	// Measure the cache and remove what crashed writes left behind
+	files, err := dc.scan()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		dc.size += file.size
+	}
+	dc.compactFiles(files)
+
+	// Start compaction goroutine
+	go dc.compactLoop()
+
+	return dc, nil
+}
+
+// path returns the file of a key, spread over subdirectories by hash prefix
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(d.dir, name[:2], name)
+}
+
+// Get retrieves a value from cache
+func (d *DiskCache) Get(ctx context.Context, key string) (string, error) {
+	path := d.path(key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", errors.New("cache miss")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	expiration, value, err := decodeDiskEntry(data)
+	if err != nil {
+		return "", err
+	}
+	if time.Now().After(expiration) {
+		return "", errors.New("cache expired")
+	}
+
+	d.touch(path)
+	return value, nil
+}
+
+// touch updates the modification time of an entry file, which tracks use for
+// eviction, unless it was updated within diskTouchInterval. It is updated
+// under the lock, so that compaction sees it before evicting the entry.
+func (d *DiskCache) touch(path string) {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) < diskTouchInterval {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+}
+
+// Set stores a value in cache
+func (d *DiskCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	data := encodeDiskEntry(time.Now().Add(ttl), value)
+	if d.maxBytes > 0 && int64(len(data)) > d.maxBytes {
+		if err := d.Delete(ctx, key); err != nil {
+			return err
+		}
+		return ErrTooLarge
+	}
+
+	path := d.path(key)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, diskTempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	var previous int64
+	if info, err := os.Stat(path); err == nil {
+		previous = info.Size()
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		d.mu.Unlock()
+		return err
+	}
+	d.size += int64(len(data)) - previous
+	full := d.maxBytes > 0 && d.size > d.maxBytes
+	d.mu.Unlock()
+
+	if full {
+		d.requestCompaction()
+	}
+
+	// Persist the rename
+	return syncDir(dir)
+}
+
+// Delete removes a value from cache
+func (d *DiskCache) Delete(ctx context.Context, key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	path := d.path(key)
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	d.size -= info.Size()
+	return nil
+}
+
+// Size returns the number of bytes of entries in the cache
+func (d *DiskCache) Size() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.size
+}
+
+// requestCompaction runs a compaction in the background unless one is
+// already pending
+func (d *DiskCache) requestCompaction() {
+	select {
+	case d.compact <- struct{}{}:
+	default:
+	}
+}
+
+// compactLoop compacts the cache periodically and when it is full
+func (d *DiskCache) compactLoop() {
+	ticker := time.NewTicker(diskCompactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-d.compact:
+		}
+		if err := d.compactNow(); err != nil {
+			utils.LogError("Failed to compact disk cache: %v", err)
+		}
+	}
+}
+
+// diskFile is an entry file found by compaction
+type diskFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+	expired bool
+}
+
+// compactNow removes temporary files and expired entries, then the least
+// recently used entries until the cache fits its size. The cache directory
+// is walked without the lock, which is only taken to remove each entry.
+func (d *DiskCache) compactNow() error {
+	files, err := d.scan()
+	if err != nil {
+		return err
+	}
+	d.compactFiles(files)
+	return nil
+}
+
+// scan lists the entry files of the cache and removes the temporary files
+// that crashed writes left behind
+func (d *DiskCache) scan() ([]diskFile, error) {
+	now := time.Now()
+	var files []diskFile
+	err := filepath.WalkDir(d.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+
+		// Temporary files of writes in progress are recent
+		if strings.HasPrefix(entry.Name(), diskTempPrefix) {
+			if now.Sub(info.ModTime()) > time.Hour {
+				_ = os.Remove(path)
+			}
+			return nil
+		}
+
+		// Unreadable entries are removed like expired ones
+		expired, err := diskEntryExpired(path, now)
+		files = append(files, diskFile{
+			path:    path,
+			size:    info.Size(),
+			modTime: info.ModTime(),
+			expired: err != nil || expired,
+		})
+		return nil
+	})
+	return files, err
+}
+
+// compactFiles removes the expired entries among files, then the least
+// recently used ones until the cache fits its size
+func (d *DiskCache) compactFiles(files []diskFile) {
+	var live []diskFile
+	for _, file := range files {
+		if file.expired {
+			d.evict(file)
+		} else {
+			live = append(live, file)
+		}
+	}
+
+	if d.maxBytes <= 0 {
+		return
+	}
+	sort.Slice(live, func(i, j int) bool {
+		return live[i].modTime.Before(live[j].modTime)
+	})
+	for _, file := range live {
+		if d.Size() <= d.maxBytes {
+			break
+		}
+		d.evict(file)
+	}
+}
+
+// evict removes an entry file found by compaction, unless it was written or
+// used since
+func (d *DiskCache) evict(file diskFile) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	info, err := os.Stat(file.path)
+	if err != nil || !info.ModTime().Equal(file.modTime) || info.Size() != file.size {
+		return
+	}
+	if err := os.Remove(file.path); err == nil {
+		d.size -= file.size
+	}
+}
+
+// syncDir flushes a directory, so that the files renamed into it survive a
+// crash
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// diskEntryExpired reads the expiration of an entry file
+func diskEntryExpired(path string, now time.Time) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 32)
+	n, err := f.Read(header)
+	if err != nil {
+		return false, err
+	}
+	expiration, _, err := decodeDiskEntry(header[:n])
+	if err != nil {
+		return false, err
+	}
+	return now.After(expiration), nil
+}
+
+// encodeDiskEntry encodes an entry as its expiration in Unix nanoseconds on
+// the first line, followed by the value
+func encodeDiskEntry(expiration time.Time, value string) []byte {
+	return []byte(strconv.FormatInt(expiration.UnixNano(), 10) + "\n" + value)
+}
+
+// decodeDiskEntry decodes an entry file, or its first line for the
+// expiration only
+func decodeDiskEntry(data []byte) (time.Time, string, error) {
+	header, value, ok := bytes.Cut(data, []byte("\n"))
+	if !ok {
+		return time.Time{}, "", errors.New("invalid cache entry")
+	}
+	nanos, err := strconv.ParseInt(string(header), 10, 64)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid cache entry: %v", err)
+	}
+	return time.Unix(0, nanos), string(value), nil
+}
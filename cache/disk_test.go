@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskCache_SetAndGet(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := cache.Set(ctx, "key1", "value1\nwith lines", 1*time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	val, err := cache.Get(ctx, "key1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if val != "value1\nwith lines" {
+		t.Errorf("Expected 'value1\\nwith lines', got '%s'", val)
+	}
+
+	if _, err := cache.Get(ctx, "nonexistent"); err == nil {
+		t.Error("Expected error for cache miss, got nil")
+	}
+}
+
+func TestDiskCache_Expiration(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	ctx := context.Background()
+
+	cache.Set(ctx, "key1", "value1", 100*time.Millisecond)
+	if _, err := cache.Get(ctx, "key1"); err != nil {
+		t.Error("Expected key to exist, but got error")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if _, err := cache.Get(ctx, "key1"); err == nil {
+		t.Error("Expected error for expired key, got nil")
+	}
+
+	// Compaction removes expired entries
+	if err := cache.compactNow(); err != nil {
+		t.Fatalf("compactNow failed: %v", err)
+	}
+	if size := cache.Size(); size != 0 {
+		t.Errorf("Expected an empty cache, got %d bytes", size)
+	}
+}
+
+func TestDiskCache_CompactionKeepsRewrittenEntries(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	ctx := context.Background()
+
+	cache.Set(ctx, "key1", "old", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	// The entry is written again after compaction found it expired
+	files, err := cache.scan()
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	cache.Set(ctx, "key1", "new", 1*time.Minute)
+	cache.compactFiles(files)
+
+	if value, err := cache.Get(ctx, "key1"); err != nil || value != "new" {
+		t.Errorf("Expected the rewritten entry to be kept, got %q, %v", value, err)
+	}
+	if size := cache.Size(); size != int64(len(encodeDiskEntry(time.Now(), "new"))) {
+		t.Errorf("Expected the size of the rewritten entry, got %d bytes", size)
+	}
+}
+
+func TestDiskCache_Touch(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	ctx := context.Background()
+
+	cache.Set(ctx, "key1", "value1", 1*time.Hour)
+	info, _ := os.Stat(cache.path("key1"))
+
+	// Recently used entries are not touched again
+	cache.Get(ctx, "key1")
+	if touched, _ := os.Stat(cache.path("key1")); !touched.ModTime().Equal(info.ModTime()) {
+		t.Error("Expected a recent entry to keep its modification time")
+	}
+
+	then := time.Now().Add(-time.Hour)
+	os.Chtimes(cache.path("key1"), then, then)
+	cache.Get(ctx, "key1")
+	if touched, _ := os.Stat(cache.path("key1")); time.Since(touched.ModTime()) > time.Minute {
+		t.Errorf("Expected a stale entry to be touched, got %v", touched.ModTime())
+	}
+}
+
+func TestDiskCache_Delete(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	ctx := context.Background()
+
+	cache.Set(ctx, "key1", "value1", 1*time.Minute)
+	if err := cache.Delete(ctx, "key1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := cache.Get(ctx, "key1"); err == nil {
+		t.Error("Expected error after delete, got nil")
+	}
+	if size := cache.Size(); size != 0 {
+		t.Errorf("Expected an empty cache, got %d bytes", size)
+	}
+}
+
+func TestDiskCache_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	cache, err := NewDiskCache(dir, 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	cache.Set(ctx, "key1", "value1", 1*time.Minute)
+
+	// Leftovers of a crash: an interrupted write and a corrupt entry
+	stale := filepath.Join(dir, diskTempPrefix+"crashed")
+	os.WriteFile(stale, []byte("partial"), 0o644)
+	old := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(stale, old, old)
+	corrupt := filepath.Join(dir, "ff", strings.Repeat("f", 64))
+	os.MkdirAll(filepath.Dir(corrupt), 0o755)
+	os.WriteFile(corrupt, []byte("garbage"), 0o644)
+
+	// A restarted cache keeps its entries
+	reopened, err := NewDiskCache(dir, 0)
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	if val, err := reopened.Get(ctx, "key1"); err != nil || val != "value1" {
+		t.Errorf("Expected 'value1' after reopening, got '%s' (%v)", val, err)
+	}
+	if size := reopened.Size(); size != cache.Size() {
+		t.Errorf("Expected size %d after reopening, got %d", cache.Size(), size)
+	}
+	for _, path := range []string{stale, corrupt} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", filepath.Base(path))
+		}
+	}
+}
+
+func TestDiskCache_SizeCap(t *testing.T) {
+	// Each entry takes 20 bytes for its expiration and 10 for the value
+	cache, err := NewDiskCache(t.TempDir(), 70)
+	if err != nil {
+		t.Fatalf("NewDiskCache failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// Entries written a while ago, key1 first
+	cache.Set(ctx, "key1", "0123456789", 1*time.Minute)
+	cache.Set(ctx, "key2", "0123456789", 1*time.Minute)
+	age := func(key string, d time.Duration) {
+		then := time.Now().Add(-d)
+		if err := os.Chtimes(cache.path(key), then, then); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+	}
+	age("key1", 3*time.Minute)
+	age("key2", 2*time.Minute)
+
+	// Reading key1 makes key2 the least recently used, which is evicted
+	// when key3 does not fit
+	cache.Get(ctx, "key1")
+	cache.Set(ctx, "key3", "0123456789", 1*time.Minute)
+	if err := cache.compactNow(); err != nil {
+		t.Fatalf("compactNow failed: %v", err)
+	}
+
+	if size := cache.Size(); size > 70 {
+		t.Errorf("Expected at most 70 bytes, got %d", size)
+	}
+	for key, kept := range map[string]bool{"key1": true, "key2": false, "key3": true} {
+		if _, err := cache.Get(ctx, key); (err == nil) != kept {
+			t.Errorf("%s: expected kept=%v, got error %v", key, kept, err)
+		}
+	}
+
+	if err := cache.Set(ctx, "key4", strings.Repeat("x", 100), 1*time.Minute); err != ErrTooLarge {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+
+	// A value that grows too large replaces the outdated one
+	if err := cache.Set(ctx, "key1", strings.Repeat("x", 100), 1*time.Minute); err != ErrTooLarge {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+	if _, err := cache.Get(ctx, "key1"); err == nil {
+		t.Error("Expected the outdated value to be removed")
+	}
+}
@@ -1,29 +1,110 @@
 package cache
 
 import (
+	"container/list"
 	"context"
 	"errors"
+	"hash/maphash"
 	"sync"
 	"time"
 )
 
-// MemoryCache is an in-memory LRU cache implementation
+const (
+	// maxShards is the number of shards of large memory caches
+	maxShards = 16
+
+	// minShardItems and minShardBytes are the least budget of a shard, so
+	// that small caches are not split into shards too small to be useful
+	minShardItems = 64
+	minShardBytes = 1 << 20
+)
+
+// ErrTooLarge is returned when a value does not fit in the budget of the
+// cache. The key is removed, so that an outdated value is not served.
+var ErrTooLarge = errors.New("value exceeds the cache memory budget")
+
+// MemoryCache is an in-memory LRU cache implementation. Keys are spread over
+// shards with their own lock and budget, so eviction is least recently used
+// per shard.
 type MemoryCache struct {
-	items    map[string]*cacheItem
-	maxItems int
-	mu       sync.RWMutex
+	shards []*memoryShard
+	seed   maphash.Seed
+}
+
+// Stats holds the counters of a memory cache
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"` // Items evicted to stay within budget
+	Items     int    `json:"items"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// memoryShard is an LRU list of items, most recently used first
+type memoryShard struct {
+	mu       sync.Mutex
+	items    map[string]*list.Element
+	lru      *list.List
+	maxItems int   // 0 for no limit
+	maxBytes int64 // 0 for no limit
+	bytes    int64
+	stats    Stats
 }
 
 type cacheItem struct {
+	key        string
 	value      string
 	expiration time.Time
 }
 
-// NewMemoryCache creates a new memory cache
+// size is the number of bytes an item counts for in the budget
+func (item *cacheItem) size() int64 {
+	return int64(len(item.key) + len(item.value))
+}
+
+// NewMemoryCache creates a new memory cache holding at most maxItems items
 func NewMemoryCache(maxItems int) *MemoryCache {
+	return NewMemoryCacheWithBudget(maxItems, 0)
+}
+
+// NewMemoryCacheWithBudget creates a new memory cache holding at most
+// maxItems items and maxBytes bytes of keys and values. Zero disables a
+// limit. The budget is split over up to 16 shards of at least 1 MiB, and an
+// item larger than the budget of its shard is rejected with ErrTooLarge, so
+// a cache of 64 MiB takes items up to 4 MiB.
+func NewMemoryCacheWithBudget(maxItems int, maxBytes int64) *MemoryCache {
+	n := maxShards
+	if maxItems > 0 {
+		n = min(n, max(maxItems/minShardItems, 1))
+	}
+	if maxBytes > 0 {
+		n = min(n, int(max(maxBytes/minShardBytes, 1)))
+	}
+
 	mc := &MemoryCache{
-		items:    make(map[string]*cacheItem),
-		maxItems: maxItems,
+		shards: make([]*memoryShard, n),
+		seed:   maphash.MakeSeed(),
+	}
+
+	// Split the budget, giving the remainder to the first shards
+	for i := range mc.shards {
+		shard := &memoryShard{
+			items: make(map[string]*list.Element),
+			lru:   list.New(),
+		}
+		if maxItems > 0 {
+			shard.maxItems = maxItems / n
+			if i < maxItems%n {
+				shard.maxItems++
+			}
+		}
+		if maxBytes > 0 {
+			shard.maxBytes = maxBytes / int64(n)
+			if int64(i) < maxBytes%int64(n) {
+				shard.maxBytes++
+			}
+		}
+		mc.shards[i] = shard
 	}
 
 	// Start cleanup goroutine
@@ -32,66 +113,104 @@ func NewMemoryCache(maxItems int) *MemoryCache {
 	return mc
 }
 
+// shard returns the shard of a key
+func (m *MemoryCache) shard(key string) *memoryShard {
+	if len(m.shards) == 1 {
+		return m.shards[0]
+	}
+	return m.shards[maphash.String(m.seed, key)%uint64(len(m.shards))]
+}
+
 // Get retrieves a value from cache
 func (m *MemoryCache) Get(ctx context.Context, key string) (string, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	item, exists := m.items[key]
+	elem, exists := s.items[key]
 	if !exists {
+		s.stats.Misses++
 		return "", errors.New("cache miss")
 	}
 
 	// Check expiration
+	item := elem.Value.(*cacheItem)
 	if time.Now().After(item.expiration) {
+		s.remove(elem)
+		s.stats.Misses++
 		return "", errors.New("cache expired")
 	}
 
+	s.lru.MoveToFront(elem)
+	s.stats.Hits++
 	return item.value, nil
 }
 
 // Set stores a value in cache
 func (m *MemoryCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	item := &cacheItem{
+		key:        key,
+		value:      value,
+		expiration: time.Now().Add(ttl),
+	}
 
-	// Evict oldest item if cache is full
-	if len(m.items) >= m.maxItems {
-		m.evictOldest()
+	s := m.shard(key)
+	if s.maxBytes > 0 && item.size() > s.maxBytes {
+		m.Delete(ctx, key)
+		return ErrTooLarge
 	}
 
-	m.items[key] = &cacheItem{
-		value:      value,
-		expiration: time.Now().Add(ttl),
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, exists := s.items[key]; exists {
+		s.remove(elem)
 	}
 
+	// Evict least recently used items until the new one fits
+	for s.lru.Len() > 0 && ((s.maxItems > 0 && s.lru.Len() >= s.maxItems) ||
+		(s.maxBytes > 0 && s.bytes+item.size() > s.maxBytes)) {
+		s.remove(s.lru.Back())
+		s.stats.Evictions++
+	}
+
+	s.items[key] = s.lru.PushFront(item)
+	s.bytes += item.size()
 	return nil
 }
 
 // Delete removes a value from cache
 func (m *MemoryCache) Delete(ctx context.Context, key string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	delete(m.items, key)
+	if elem, exists := s.items[key]; exists {
+		s.remove(elem)
+	}
 	return nil
 }
 
-// evictOldest removes the oldest item from cache (must be called with lock held)
-func (m *MemoryCache) evictOldest() {
-	var oldestKey string
-	var oldestTime time.Time
-
-	for key, item := range m.items {
-		if oldestKey == "" || item.expiration.Before(oldestTime) {
-			oldestKey = key
-			oldestTime = item.expiration
-		}
+// Stats returns the counters of the cache summed over its shards
+func (m *MemoryCache) Stats() Stats {
+	var stats Stats
+	for _, s := range m.shards {
+		s.mu.Lock()
+		stats.Hits += s.stats.Hits
+		stats.Misses += s.stats.Misses
+		stats.Evictions += s.stats.Evictions
+		stats.Items += s.lru.Len()
+		stats.Bytes += s.bytes
+		s.mu.Unlock()
 	}
+	return stats
+}
 
-	if oldestKey != "" {
-		delete(m.items, oldestKey)
-	}
+// remove removes an item from the shard (must be called with lock held)
+func (s *memoryShard) remove(elem *list.Element) {
+	item := s.lru.Remove(elem).(*cacheItem)
+	delete(s.items, item.key)
+	s.bytes -= item.size()
 }
 
 // cleanup runs periodically to remove expired items
@@ -100,13 +219,15 @@ func (m *MemoryCache) cleanup() {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		m.mu.Lock()
 		now := time.Now()
-		for key, item := range m.items {
-			if now.After(item.expiration) {
-				delete(m.items, key)
+		for _, s := range m.shards {
+			s.mu.Lock()
+			for _, elem := range s.items {
+				if now.After(elem.Value.(*cacheItem).expiration) {
+					s.remove(elem)
+				}
 			}
+			s.mu.Unlock()
 		}
-		m.mu.Unlock()
 	}
 }
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -99,14 +101,105 @@ func TestMemoryCache_Eviction(t *testing.T) {
 	cache.Set(ctx, "key4", "value4", 1*time.Minute)
 
 	// Should have evicted oldest item
-	// Note: This is a simple test - actual LRU would need more sophisticated testing
-	cache.mu.RLock()
-	count := len(cache.items)
-	cache.mu.RUnlock()
-
-	if count > 3 {
+	if count := cache.Stats().Items; count > 3 {
 		t.Errorf("Expected max 3 items, got %d", count)
 	}
+	if _, err := cache.Get(ctx, "key1"); err == nil {
+		t.Error("Expected key1 to be evicted")
+	}
+}
+
+func TestMemoryCache_LRU(t *testing.T) {
+	cache := NewMemoryCache(3)
+	ctx := context.Background()
+
+	cache.Set(ctx, "key1", "value1", 1*time.Minute)
+	cache.Set(ctx, "key2", "value2", 1*time.Minute)
+	cache.Set(ctx, "key3", "value3", 1*time.Minute)
+
+	// Reading key1 makes key2 the least recently used
+	cache.Get(ctx, "key1")
+	cache.Set(ctx, "key4", "value4", 1*time.Minute)
+
+	for key, kept := range map[string]bool{"key1": true, "key2": false, "key3": true, "key4": true} {
+		if _, err := cache.Get(ctx, key); (err == nil) != kept {
+			t.Errorf("%s: expected kept=%v, got error %v", key, kept, err)
+		}
+	}
+}
+
+func TestMemoryCache_ByteBudget(t *testing.T) {
+	cache := NewMemoryCacheWithBudget(0, 20)
+	ctx := context.Background()
+
+	// Each item counts its key and value, 10 bytes
+	cache.Set(ctx, "key1", "value1", 1*time.Minute)
+	cache.Set(ctx, "key2", "value2", 1*time.Minute)
+	cache.Set(ctx, "key3", "value3", 1*time.Minute)
+
+	stats := cache.Stats()
+	if stats.Items != 2 || stats.Bytes != 20 || stats.Evictions != 1 {
+		t.Errorf("Expected 2 items of 20 bytes and 1 eviction, got %+v", stats)
+	}
+	if _, err := cache.Get(ctx, "key1"); err == nil {
+		t.Error("Expected key1 to be evicted")
+	}
+
+	// Replacing a value updates the size
+	cache.Set(ctx, "key3", "v", 1*time.Minute)
+	if stats := cache.Stats(); stats.Bytes != 15 {
+		t.Errorf("Expected 15 bytes, got %d", stats.Bytes)
+	}
+
+	if err := cache.Set(ctx, "key5", strings.Repeat("x", 20), 1*time.Minute); err != ErrTooLarge {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+
+	// A value that grows too large replaces the outdated one
+	if err := cache.Set(ctx, "key3", strings.Repeat("x", 20), 1*time.Minute); err != ErrTooLarge {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+	if _, err := cache.Get(ctx, "key3"); err == nil {
+		t.Error("Expected the outdated value to be removed")
+	}
+}
+
+func TestMemoryCache_Stats(t *testing.T) {
+	cache := NewMemoryCache(10)
+	ctx := context.Background()
+
+	cache.Set(ctx, "key1", "value1", 1*time.Minute)
+	cache.Set(ctx, "key2", "value2", 50*time.Millisecond)
+	cache.Get(ctx, "key1")
+	cache.Get(ctx, "key1")
+	cache.Get(ctx, "missing")
+	time.Sleep(100 * time.Millisecond)
+	cache.Get(ctx, "key2")
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Items != 1 || stats.Evictions != 0 {
+		t.Errorf("Expected 2 hits, 2 misses and 1 item, got %+v", stats)
+	}
+}
+
+func TestMemoryCache_Shards(t *testing.T) {
+	cache := NewMemoryCacheWithBudget(1024, 64<<20)
+	ctx := context.Background()
+	if len(cache.shards) != maxShards {
+		t.Fatalf("Expected %d shards, got %d", maxShards, len(cache.shards))
+	}
+
+	for i := 0; i < 2000; i++ {
+		cache.Set(ctx, fmt.Sprintf("key%d", i), "value", 1*time.Minute)
+	}
+
+	stats := cache.Stats()
+	if stats.Items > 1024 || stats.Items+int(stats.Evictions) != 2000 {
+		t.Errorf("Expected at most 1024 items and the rest evicted, got %+v", stats)
+	}
+	if _, err := cache.Get(ctx, "key1999"); err != nil {
+		t.Error("Expected the last key to be cached")
+	}
 }
 
 func TestMemoryCache_Concurrent(t *testing.T) {
@@ -1,11 +1,16 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
+	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
+	"syscall"
 	"time"
 
 	"github.com/jean-jacket/grss/config"
@@ -48,33 +53,106 @@ func New(cfg *config.Config) *Client {
 	}
 }
 
-// Get performs a GET request with retry logic
-func (c *Client) Get(reqURL string, headers map[string]string) ([]byte, error) {
-	req, err := http.NewRequest("GET", reqURL, nil)
+// ErrNonPublicAddress is returned when a public client is asked to connect to
+// a loopback, private, link-local or unspecified address
+var ErrNonPublicAddress = errors.New("refusing to connect to a non-public address")
+
+// NewPublic creates an HTTP client that only connects to public addresses,
+// for URLs taken from untrusted content. The resolved address of every
+// connection is checked, so redirects and DNS rebinding are covered. The
+// configured proxy may be private, but requests through it are not checked.
+func NewPublic(cfg *config.Config) *Client {
+	c := New(cfg)
+
+	var proxyAddr string
+	if cfg.Proxy.URI != "" {
+		if proxyURL, err := url.Parse(cfg.Proxy.URI); err == nil {
+			proxyAddr = canonicalAddr(proxyURL)
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	public := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: refuseNonPublic}
+	transport := c.httpClient.Transport.(*http.Transport)
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == proxyAddr {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		return public.DialContext(ctx, network, addr)
+	}
+	return c
+}
+
+// refuseNonPublic is a net.Dialer Control hook that refuses connections to
+// loopback, private, link-local and unspecified addresses
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, ip)
 	}
+	return nil
+}
 
-	// Set headers
-	for key, value := range headers {
-		req.Header.Set(key, value)
+// canonicalAddr returns the host:port a URL connects to
+func canonicalAddr(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "socks5", "socks5h":
+			port = "1080"
+		default:
+			port = "80"
+		}
 	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
 
-	// Set User-Agent
-	if c.config.UserAgent != "" {
-		req.Header.Set("User-Agent", c.config.UserAgent)
-	} else {
-		// Use random user agent
-		req.Header.Set("User-Agent", defaultUserAgents[rand.Intn(len(defaultUserAgents))])
+// Get performs a GET request with retry logic
+func (c *Client) Get(reqURL string, headers map[string]string) ([]byte, error) {
+	req, err := c.newRequest("GET", reqURL, nil, headers)
+	if err != nil {
+		return nil, err
 	}
 
 	// Perform request with retry
 	return c.doWithRetry(req)
 }
 
+// GetStream performs a GET request with retry logic and returns the response
+// without reading it. The caller must close the response body.
+func (c *Client) GetStream(reqURL string, headers map[string]string) (*http.Response, error) {
+	req, err := c.newRequest("GET", reqURL, nil, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.sendWithRetry(req)
+}
+
 // Post performs a POST request with retry logic
 func (c *Client) Post(reqURL string, body io.Reader, headers map[string]string) ([]byte, error) {
-	req, err := http.NewRequest("POST", reqURL, body)
+	req, err := c.newRequest("POST", reqURL, body, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.doWithRetry(req)
+}
+
+// newRequest creates a request with the given headers and the User-Agent
+func (c *Client) newRequest(method, reqURL string, body io.Reader, headers map[string]string) (*http.Request, error) {
+	req, err := http.NewRequest(method, reqURL, body)
 	if err != nil {
 		return nil, err
 	}
@@ -88,14 +166,27 @@ func (c *Client) Post(reqURL string, body io.Reader, headers map[string]string)
 	if c.config.UserAgent != "" {
 		req.Header.Set("User-Agent", c.config.UserAgent)
 	} else {
+		// Use random user agent
 		req.Header.Set("User-Agent", defaultUserAgents[rand.Intn(len(defaultUserAgents))])
 	}
 
-	return c.doWithRetry(req)
+	return req, nil
 }
 
-// doWithRetry performs the request with retry logic
+// doWithRetry performs the request with retry logic and reads the response body
 func (c *Client) doWithRetry(req *http.Request) ([]byte, error) {
+	resp, err := c.sendWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// sendWithRetry performs the request with retry logic and returns the first
+// successful response
+func (c *Client) sendWithRetry(req *http.Request) (*http.Response, error) {
 	var lastErr error
 	maxRetries := c.config.RequestRetry
 
@@ -112,6 +203,9 @@ func (c *Client) doWithRetry(req *http.Request) ([]byte, error) {
 		// Log request
 		utils.LogRequest(req.Method, req.URL.String(), resp, duration, err)
 
+		if errors.Is(err, ErrNonPublicAddress) {
+			return nil, err
+		}
 		if err != nil {
 			lastErr = err
 			// Exponential backoff
@@ -123,13 +217,7 @@ func (c *Client) doWithRetry(req *http.Request) ([]byte, error) {
 
 		// Check status code
 		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			// Success - read body
-			defer resp.Body.Close()
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return nil, err
-			}
-			return body, nil
+			return resp, nil
 		}
 
 		// Check if we should retry based on status code
@@ -234,3 +234,28 @@ func TestClient_CustomUserAgent(t *testing.T) {
 		t.Fatalf("GET failed: %v", err)
 	}
 }
+
+func TestRefuseNonPublic(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"10.1.2.3:80", false},
+		{"172.16.0.1:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"0.0.0.0:80", false},
+		{"[::1]:80", false},
+		{"[fe80::1]:80", false},
+		{"[fd00::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+	}
+	for _, tt := range tests {
+		if err := refuseNonPublic("tcp", tt.address, nil); (err == nil) != tt.allowed {
+			t.Errorf("%s: expected allowed=%v, got %v", tt.address, tt.allowed, err)
+		}
+	}
+}
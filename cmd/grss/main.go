@@ -4,17 +4,22 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"regexp"
 	"strings"
 	"time"
+	_ "time/tzdata" // Embed the timezone database for route timezones and tz= in scratch images
 
 	"github.com/gin-gonic/gin"
 	"github.com/jean-jacket/grss/cache"
 	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+	"github.com/jean-jacket/grss/imageproxy"
 	"github.com/jean-jacket/grss/middleware"
 	"github.com/jean-jacket/grss/routes/registry"
+	"github.com/jean-jacket/grss/utils"
 
 	// Import routes package to auto-register all route namespaces
 	_ "github.com/jean-jacket/grss/routes"
@@ -47,8 +52,8 @@ func main() {
 	var cacheInstance cache.Cache
 	switch cfg.Cache.Type {
 	case "memory":
-		cacheInstance = cache.NewMemoryCache(cfg.Cache.MemoryMax)
-		log.Printf("Using memory cache with max %d items", cfg.Cache.MemoryMax)
+		cacheInstance = cache.NewMemoryCacheWithBudget(cfg.Cache.MemoryMax, cfg.Cache.MemoryMaxSize)
+		log.Printf("Using memory cache with max %d items and %d bytes", cfg.Cache.MemoryMax, cfg.Cache.MemoryMaxSize)
 	case "redis":
 		redisCache, err := cache.NewRedisCache(cfg.Redis.URL)
 		if err != nil {
@@ -56,24 +61,44 @@ func main() {
 		}
 		cacheInstance = redisCache
 		log.Printf("Using Redis cache at %s", cfg.Redis.URL)
+	case "disk":
+		diskCache, err := cache.NewDiskCache(cfg.Disk.Path, cfg.Disk.MaxSize)
+		if err != nil {
+			log.Fatalf("Failed to open disk cache: %v", err)
+		}
+		cacheInstance = diskCache
+		log.Printf("Using disk cache at %s with max %d bytes", cfg.Disk.Path, cfg.Disk.MaxSize)
 	default:
 		log.Printf("Cache disabled")
 	}
 
 	// Middleware chain (order matters!)
+	// Middlewares post-process the handler result in reverse order, so
+	// Parameter is registered after Template to transform the data before
+	// it is rendered, and FullText after Parameter so that filters, brief and
+	// title truncation apply to the extracted articles. RouteCache comes last
+	// to cache the handler result itself, while Cache optionally caches the
+	// rendered output.
 	router.Use(middleware.Logger())
 	router.Use(middleware.AccessControl())
 	router.Use(middleware.Header())
-	router.Use(middleware.Parameter())
-	if cacheInstance != nil {
+	if cacheInstance != nil && cfg.Cache.OutputExpire > 0 {
 		router.Use(middleware.Cache(cacheInstance))
 	}
 	router.Use(middleware.Template())
+	router.Use(middleware.Hotlink())
+	router.Use(middleware.Parameter(cacheInstance))
+	router.Use(middleware.FullText(cacheInstance))
+	if cacheInstance != nil {
+		router.Use(middleware.RouteCache(cacheInstance))
+	}
 
 	// Built-in routes
 	router.GET("/", homeHandler)
-	router.GET("/healthz", healthzHandler)
+	router.GET("/healthz", healthzHandler(cacheInstance))
 	router.GET("/robots.txt", robotsHandler)
+	router.GET(middleware.StylesheetPath, stylesheetHandler)
+	router.GET(imageproxy.Path, imageproxy.Handler(cacheInstance))
 
 	// Mount all registered routes
 	registry.MountRoutes(router)
@@ -85,7 +110,8 @@ func main() {
 	}
 
 	log.Printf("Starting GRSS on %s", addr)
-	if err := router.Run(addr); err != nil {
+	// Path suffixes such as .atom select the output format before routing
+	if err := http.ListenAndServe(addr, middleware.FormatExtension(router)); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
@@ -117,10 +143,19 @@ func homeHandler(c *gin.Context) {
 
     <h2>Query Parameters</h2>
     <ul>
-        <li><code>format</code>: Output format (rss, atom, json) - default: rss</li>
+        <li><code>format</code>: Output format (rss, atom, json, rdf, activitystreams, html, ics, csv, ndjson) - default: rss. Also selected by the <code>Accept</code> header or a path suffix such as <code>.atom</code></li>
         <li><code>limit</code>: Limit number of items</li>
-        <li><code>filter</code>: Filter items by regex</li>
-        <li><code>sorted</code>: Sort by date (asc, desc)</li>
+        <li><code>filter</code>: Filter items by regex (also <code>filter_title</code>, <code>filter_author</code>, <code>filter_category</code> and the <code>filterout</code> counterparts)</li>
+        <li><code>expr</code>: Filter with a boolean expression, e.g. <code>category == "bug" &amp;&amp; age &lt; 7d</code></li>
+        <li><code>offset</code>: Skip the first N items</li>
+        <li><code>sorted</code>: Sort by date (asc, desc) or by fields, e.g. <code>author,date:desc</code></li>
+        <li><code>dedup</code>: Collapse duplicate items (guid, link, title)</li>
+        <li><code>rewrite</code>: Rewrite fields with sed syntax, e.g. <code>title:s/^\[Ad\] *//</code></li>
+        <li><code>tz</code>: Render dates in a timezone, e.g. <code>Europe/Berlin</code></li>
+        <li><code>date_fallback</code>: Handle items without a date (drop, zero, first_seen)</li>
+        <li><code>brief</code>: Shorten each item's content to N characters</li>
+        <li><code>mode=fulltext</code>: Fetch each item's page and use its main content</li>
+        <li><code>mode=digest</code>: Group items into daily or weekly entries (<code>period=day|week</code>)</li>
     </ul>
 
     <h2>Links</h2>
@@ -134,11 +169,18 @@ func homeHandler(c *gin.Context) {
 	c.String(200, html)
 }
 
-// healthzHandler serves the health check endpoint
-func healthzHandler(c *gin.Context) {
-	c.JSON(200, gin.H{
-		"status": "ok",
-	})
+// healthzHandler serves the health check endpoint, with the counters of
+// caches that keep them
+func healthzHandler(cacheInstance cache.Cache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response := gin.H{
+			"status": "ok",
+		}
+		if stats, ok := cacheInstance.(interface{ Stats() cache.Stats }); ok {
+			response["cache"] = stats.Stats()
+		}
+		c.JSON(200, response)
+	}
 }
 
 // robotsHandler serves the robots.txt file
@@ -153,6 +195,12 @@ func robotsHandler(c *gin.Context) {
 	c.String(200, robots)
 }
 
+// stylesheetHandler serves the XSLT stylesheet referenced by RSS and Atom feeds
+func stylesheetHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(200, "text/xsl; charset=utf-8", feed.StylesheetXSLT)
+}
+
 // testRouteHandler tests a route and prints debug information
 func testRouteHandler(routePath string, limit int) {
 	// Ensure route starts with /
@@ -216,6 +264,9 @@ func testRouteHandler(routePath string, limit int) {
 		}
 	}
 
+	// Run the handler in the route timezone
+	c.Set(registry.ContextKeyLocation, matchedRoute.Route.Location())
+
 	// Execute the handler and measure time
 	fmt.Println("Handler logs:")
 	startTime := time.Now()
@@ -281,14 +332,14 @@ func testRouteHandler(routePath string, limit int) {
 			item := feedData.Item[itemIdx]
 
 			// Truncate title if too long
-			title := truncateString(item.Title, colTitle)
+			title := utils.Truncate(item.Title, colTitle, "...")
 
 			// Clean and truncate description
-			desc := item.Description
+			desc := item.SummaryText()
 			desc = strings.ReplaceAll(desc, "\n", " ")
 			desc = strings.ReplaceAll(desc, "\r", " ")
 			desc = strings.Join(strings.Fields(desc), " ")
-			desc = truncateString(desc, colDescription)
+			desc = utils.Truncate(desc, colDescription, "...")
 			if desc == "" {
 				desc = "-"
 			}
@@ -327,14 +378,14 @@ func testRouteHandler(routePath string, limit int) {
 					item := feedData.Item[itemIdx]
 
 					// Truncate title if too long
-					title := truncateString(item.Title, colTitle)
+					title := utils.Truncate(item.Title, colTitle, "...")
 
 					// Clean and truncate description
-					desc := item.Description
+					desc := item.SummaryText()
 					desc = strings.ReplaceAll(desc, "\n", " ")
 					desc = strings.ReplaceAll(desc, "\r", " ")
 					desc = strings.Join(strings.Fields(desc), " ")
-					desc = truncateString(desc, colDescription)
+					desc = utils.Truncate(desc, colDescription, "...")
 					if desc == "" {
 						desc = "-"
 					}
@@ -357,18 +408,6 @@ func testRouteHandler(routePath string, limit int) {
 	fmt.Println()
 }
 
-// truncateString truncates a string to fit within maxLen, accounting for multi-byte characters
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	// Account for "..." suffix
-	if maxLen < 3 {
-		return s[:maxLen]
-	}
-	return s[:maxLen-3] + "..."
-}
-
 // formatDateWithRelative formats a date with relative time if within a week
 func formatDateWithRelative(t time.Time) string {
 	now := time.Now()
@@ -28,10 +28,13 @@ type Config struct {
 
 	// Cache Configuration
 	Cache struct {
-		Type          string // "memory", "redis", or "" (disabled)
-		RouteExpire   time.Duration
+		Type          string        // "memory", "redis", "disk", or "" (disabled)
+		RouteExpire   time.Duration // Feed data of routes
+		StaleExpire   time.Duration // Stale feed data served while refreshing or on errors
+		OutputExpire  time.Duration // Rendered output, 0 disables the output cache
 		ContentExpire time.Duration
 		MemoryMax     int
+		MemoryMaxSize int64 // Bytes, 0 for no limit
 	}
 
 	// Redis Configuration
@@ -39,6 +42,12 @@ type Config struct {
 		URL string
 	}
 
+	// Disk Cache Configuration
+	Disk struct {
+		Path    string
+		MaxSize int64 // Bytes, 0 for no limit
+	}
+
 	// Proxy Configuration
 	Proxy struct {
 		URI      string
@@ -57,10 +66,19 @@ type Config struct {
 
 	// Feed Features
 	Hotlink struct {
-		Template string
+		Template     string // Placeholders: {url}, {url_encoded}, {host}, {path}, {base}, {sig}
+		IncludeHosts string // Regex of image hosts to rewrite, empty for all
+		ExcludeHosts string // Regex of image hosts to keep
+	}
+	ImageProxy struct {
+		Rewrite      bool     // Rewrite item images to the built-in proxy
+		MaxSize      int64    // Bytes
+		AllowedTypes []string // Content type prefixes
 	}
-	TitleLengthLimit int
+	TitleLengthLimit  int
 	FilterRegexEngine string // "re2" or "regexp"
+	FeedStylesheet    bool   // Reference the XSLT stylesheet from RSS and Atom output
+	RewriteRules      string // Path to a JSON file of item rewrite rules
 
 	// OpenAI Configuration
 	OpenAI struct {
@@ -110,12 +128,19 @@ func Load() *Config {
 	// Cache Configuration
 	C.Cache.Type = viper.GetString("CACHE_TYPE")
 	C.Cache.RouteExpire = time.Duration(viper.GetInt("CACHE_ROUTE_EXPIRE")) * time.Second
+	C.Cache.StaleExpire = time.Duration(viper.GetInt("CACHE_STALE_EXPIRE")) * time.Second
+	C.Cache.OutputExpire = time.Duration(viper.GetInt("CACHE_OUTPUT_EXPIRE")) * time.Second
 	C.Cache.ContentExpire = time.Duration(viper.GetInt("CACHE_CONTENT_EXPIRE")) * time.Second
 	C.Cache.MemoryMax = viper.GetInt("MEMORY_MAX")
+	C.Cache.MemoryMaxSize = viper.GetInt64("MEMORY_MAX_SIZE")
 
 	// Redis Configuration
 	C.Redis.URL = viper.GetString("REDIS_URL")
 
+	// Disk Cache Configuration
+	C.Disk.Path = viper.GetString("CACHE_DISK_PATH")
+	C.Disk.MaxSize = viper.GetInt64("CACHE_DISK_MAX_SIZE")
+
 	// Proxy Configuration
 	C.Proxy.URI = viper.GetString("PROXY_URI")
 	proxyURIs := viper.GetString("PROXY_URIS")
@@ -133,8 +158,17 @@ func Load() *Config {
 
 	// Feed Features
 	C.Hotlink.Template = viper.GetString("HOTLINK_TEMPLATE")
+	C.Hotlink.IncludeHosts = viper.GetString("HOTLINK_INCLUDE_HOSTS")
+	C.Hotlink.ExcludeHosts = viper.GetString("HOTLINK_EXCLUDE_HOSTS")
+	C.ImageProxy.Rewrite = viper.GetBool("IMAGE_PROXY_REWRITE")
+	C.ImageProxy.MaxSize = viper.GetInt64("IMAGE_PROXY_MAX_SIZE")
+	if allowedTypes := viper.GetString("IMAGE_PROXY_TYPES"); allowedTypes != "" {
+		C.ImageProxy.AllowedTypes = strings.Split(allowedTypes, ",")
+	}
 	C.TitleLengthLimit = viper.GetInt("TITLE_LENGTH_LIMIT")
 	C.FilterRegexEngine = viper.GetString("FILTER_REGEX_ENGINE")
+	C.FeedStylesheet = viper.GetBool("FEED_STYLESHEET")
+	C.RewriteRules = viper.GetString("REWRITE_RULES")
 
 	// OpenAI Configuration
 	C.OpenAI.APIKey = viper.GetString("OPENAI_API_KEY")
@@ -171,12 +205,19 @@ func setDefaults() {
 	// Cache defaults
 	viper.SetDefault("CACHE_TYPE", "memory")
 	viper.SetDefault("CACHE_ROUTE_EXPIRE", 300)
+	viper.SetDefault("CACHE_STALE_EXPIRE", 86400)
+	viper.SetDefault("CACHE_OUTPUT_EXPIRE", 0)
 	viper.SetDefault("CACHE_CONTENT_EXPIRE", 3600)
 	viper.SetDefault("MEMORY_MAX", 256)
+	viper.SetDefault("MEMORY_MAX_SIZE", 64*1024*1024)
 
 	// Redis defaults
 	viper.SetDefault("REDIS_URL", "")
 
+	// Disk cache defaults
+	viper.SetDefault("CACHE_DISK_PATH", "data/cache")
+	viper.SetDefault("CACHE_DISK_MAX_SIZE", 512*1024*1024)
+
 	// Proxy defaults
 	viper.SetDefault("PROXY_URI", "")
 	viper.SetDefault("PROXY_URIS", "")
@@ -191,8 +232,15 @@ func setDefaults() {
 
 	// Feed feature defaults
 	viper.SetDefault("HOTLINK_TEMPLATE", "")
+	viper.SetDefault("HOTLINK_INCLUDE_HOSTS", "")
+	viper.SetDefault("HOTLINK_EXCLUDE_HOSTS", "")
+	viper.SetDefault("IMAGE_PROXY_REWRITE", false)
+	viper.SetDefault("IMAGE_PROXY_MAX_SIZE", 10*1024*1024)
+	viper.SetDefault("IMAGE_PROXY_TYPES", "image/,video/,audio/")
 	viper.SetDefault("TITLE_LENGTH_LIMIT", 150)
 	viper.SetDefault("FILTER_REGEX_ENGINE", "re2")
+	viper.SetDefault("FEED_STYLESHEET", false)
+	viper.SetDefault("REWRITE_RULES", "")
 
 	// OpenAI defaults
 	viper.SetDefault("OPENAI_API_KEY", "")
@@ -25,12 +25,24 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.Cache.RouteExpire != 300*time.Second {
 		t.Errorf("Expected default route expire 300s, got %v", cfg.Cache.RouteExpire)
 	}
+	if cfg.Cache.StaleExpire != 24*time.Hour {
+		t.Errorf("Expected default stale expire 24h, got %v", cfg.Cache.StaleExpire)
+	}
+	if cfg.Cache.OutputExpire != 0 {
+		t.Errorf("Expected the output cache to be disabled by default, got %v", cfg.Cache.OutputExpire)
+	}
 	if cfg.Cache.ContentExpire != 3600*time.Second {
 		t.Errorf("Expected default content expire 3600s, got %v", cfg.Cache.ContentExpire)
 	}
 	if cfg.Cache.MemoryMax != 256 {
 		t.Errorf("Expected default memory max 256, got %d", cfg.Cache.MemoryMax)
 	}
+	if cfg.Cache.MemoryMaxSize != 64*1024*1024 {
+		t.Errorf("Expected default memory max size 64 MiB, got %d", cfg.Cache.MemoryMaxSize)
+	}
+	if cfg.Disk.Path != "data/cache" || cfg.Disk.MaxSize != 512*1024*1024 {
+		t.Errorf("Expected default disk cache data/cache of 512 MiB, got %s of %d", cfg.Disk.Path, cfg.Disk.MaxSize)
+	}
 	if cfg.RequestRetry != 2 {
 		t.Errorf("Expected default request retry 2, got %d", cfg.RequestRetry)
 	}
@@ -67,6 +79,8 @@ func TestLoad_CustomCache(t *testing.T) {
 	os.Setenv("CACHE_TYPE", "redis")
 	os.Setenv("REDIS_URL", "redis://localhost:6379/1")
 	os.Setenv("CACHE_ROUTE_EXPIRE", "600")
+	os.Setenv("CACHE_STALE_EXPIRE", "3600")
+	os.Setenv("CACHE_OUTPUT_EXPIRE", "60")
 	os.Setenv("CACHE_CONTENT_EXPIRE", "7200")
 
 	cfg := Load()
@@ -80,6 +94,12 @@ func TestLoad_CustomCache(t *testing.T) {
 	if cfg.Cache.RouteExpire != 600*time.Second {
 		t.Errorf("Expected route expire 600s, got %v", cfg.Cache.RouteExpire)
 	}
+	if cfg.Cache.StaleExpire != time.Hour {
+		t.Errorf("Expected stale expire 1h, got %v", cfg.Cache.StaleExpire)
+	}
+	if cfg.Cache.OutputExpire != 60*time.Second {
+		t.Errorf("Expected output expire 60s, got %v", cfg.Cache.OutputExpire)
+	}
 	if cfg.Cache.ContentExpire != 7200*time.Second {
 		t.Errorf("Expected content expire 7200s, got %v", cfg.Cache.ContentExpire)
 	}
@@ -160,6 +180,11 @@ func TestLoad_FeedSettings(t *testing.T) {
 	os.Setenv("TITLE_LENGTH_LIMIT", "200")
 	os.Setenv("FILTER_REGEX_ENGINE", "regexp")
 	os.Setenv("HOTLINK_TEMPLATE", "https://proxy.example.com/{url}")
+	os.Setenv("HOTLINK_EXCLUDE_HOSTS", `^cdn\.example\.com$`)
+	os.Setenv("IMAGE_PROXY_REWRITE", "true")
+	os.Setenv("IMAGE_PROXY_TYPES", "image/")
+	os.Setenv("FEED_STYLESHEET", "true")
+	os.Setenv("REWRITE_RULES", "/etc/grss/rewrite.json")
 
 	cfg := Load()
 
@@ -172,6 +197,21 @@ func TestLoad_FeedSettings(t *testing.T) {
 	if cfg.Hotlink.Template != "https://proxy.example.com/{url}" {
 		t.Errorf("Expected hotlink template 'https://proxy.example.com/{url}', got '%s'", cfg.Hotlink.Template)
 	}
+	if cfg.Hotlink.ExcludeHosts != `^cdn\.example\.com$` {
+		t.Errorf("Expected hotlink exclude hosts, got '%s'", cfg.Hotlink.ExcludeHosts)
+	}
+	if !cfg.ImageProxy.Rewrite || len(cfg.ImageProxy.AllowedTypes) != 1 || cfg.ImageProxy.AllowedTypes[0] != "image/" {
+		t.Errorf("Expected image proxy settings, got %+v", cfg.ImageProxy)
+	}
+	if cfg.ImageProxy.MaxSize != 10*1024*1024 {
+		t.Errorf("Expected default image proxy max size, got %d", cfg.ImageProxy.MaxSize)
+	}
+	if !cfg.FeedStylesheet {
+		t.Error("Expected feed stylesheet to be enabled")
+	}
+	if cfg.RewriteRules != "/etc/grss/rewrite.json" {
+		t.Errorf("Expected rewrite rules path, got '%s'", cfg.RewriteRules)
+	}
 }
 
 func TestLoad_DisallowRobot(t *testing.T) {
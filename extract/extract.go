@@ -0,0 +1,311 @@
+// Package extract finds the main content of an article page, in the spirit
+// of Mozilla's Readability: paragraphs are scored by their text and the
+// container with the best score is kept.
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jean-jacket/grss/utils"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// Article is the main content extracted from a page
+type Article struct {
+	Title   string `json:"title,omitempty"`
+	Byline  string `json:"byline,omitempty"`
+	Excerpt string `json:"excerpt,omitempty"`
+	Content string `json:"content"` // HTML
+}
+
+// ErrNoContent is returned when a page has no recognizable main content
+var ErrNoContent = errors.New("no article content found")
+
+// minContentLength is the minimum length in bytes of the extracted text
+const minContentLength = 140
+
+var (
+	// Class names and IDs of page chrome and of article bodies
+	unlikelyCandidates = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|foot|header|legends|menu|modal|nav|newsletter|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|ad-break|agegate|pagination|pager`)
+	maybeCandidate     = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveWeight     = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|prose|text|blog|story`)
+	negativeWeight     = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// removedElements are never part of the article content
+const removedElements = "script, style, noscript, template, iframe, object, embed, form, button, input, select, textarea, svg, canvas, nav, aside, header, footer, link, meta"
+
+// scoredElements are the elements whose text adds to the score of their ancestors
+const scoredElements = "p, pre, td, blockquote, section > div, article > div"
+
+// keptAttributes are the attributes kept on the extracted content
+var keptAttributes = map[string]bool{
+	"href": true, "src": true, "srcset": true, "alt": true, "title": true,
+	"width": true, "height": true, "colspan": true, "rowspan": true,
+	"datetime": true, "cite": true, "lang": true, "dir": true,
+}
+
+// Extract returns the main content of an HTML page. Relative links and image
+// sources are resolved against pageURL.
+func Extract(body []byte, pageURL string) (*Article, error) {
+	reader, err := charset.NewReader(bytes.NewReader(body), "")
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	article := &Article{
+		Title:   metaContent(doc, "og:title", "twitter:title"),
+		Byline:  metaContent(doc, "author", "article:author"),
+		Excerpt: metaContent(doc, "og:description", "description", "twitter:description"),
+	}
+	if article.Title == "" {
+		article.Title = strings.TrimSpace(doc.Find("title").First().Text())
+	}
+
+	doc.Find(removedElements).Remove()
+	doc.Find("[hidden], [aria-hidden=true], [role=navigation], [role=complementary], [role=dialog]").Remove()
+	removeUnlikelyCandidates(doc)
+
+	top := topCandidate(doc)
+	if top == nil {
+		return nil, ErrNoContent
+	}
+
+	content := collectContent(top)
+	base, _ := url.Parse(pageURL)
+	cleanContent(content, base)
+
+	output, err := content.Html()
+	if err != nil {
+		return nil, err
+	}
+	if len(utils.StripHTML(output)) < minContentLength {
+		return nil, ErrNoContent
+	}
+	article.Content = strings.TrimSpace(output)
+
+	return article, nil
+}
+
+// metaContent returns the content of the first non-empty meta tag with one
+// of the given names or properties
+func metaContent(doc *goquery.Document, names ...string) string {
+	for _, name := range names {
+		selector := `meta[name="` + name + `"], meta[property="` + name + `"]`
+		if content, ok := doc.Find(selector).First().Attr("content"); ok && strings.TrimSpace(content) != "" {
+			return strings.TrimSpace(content)
+		}
+	}
+	return ""
+}
+
+// removeUnlikelyCandidates drops elements that look like page chrome unless
+// they may also hold the article
+func removeUnlikelyCandidates(doc *goquery.Document) {
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "article" || goquery.NodeName(s) == "main" {
+			return
+		}
+		match := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if unlikelyCandidates.MatchString(match) && !maybeCandidate.MatchString(match) {
+			s.Remove()
+		}
+	})
+}
+
+// topCandidate scores the ancestors of every paragraph and returns the best
+// one, adjusted for link density
+func topCandidate(doc *goquery.Document) *goquery.Selection {
+	scores := map[*html.Node]float64{}
+	var candidates []*goquery.Selection
+
+	addScore := func(s *goquery.Selection, score float64) {
+		node := s.Get(0)
+		if _, ok := scores[node]; !ok {
+			scores[node] = initialScore(s)
+			candidates = append(candidates, s)
+		}
+		scores[node] += score
+	}
+
+	doc.Find(scoredElements).Each(func(_ int, s *goquery.Selection) {
+		text := strings.TrimSpace(s.Text())
+		if len(text) < 25 {
+			return
+		}
+
+		// One point for the paragraph, one per comma and one per 100 characters
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+
+		parent := s.Parent()
+		if parent.Length() == 0 || goquery.NodeName(parent) == "html" {
+			return
+		}
+		addScore(parent, score)
+		if grandparent := parent.Parent(); grandparent.Length() > 0 && goquery.NodeName(grandparent) != "html" {
+			addScore(grandparent, score/2)
+		}
+	})
+
+	var top *goquery.Selection
+	best := 0.0
+	for _, candidate := range candidates {
+		score := scores[candidate.Get(0)] * (1 - linkDensity(candidate))
+		scores[candidate.Get(0)] = score
+		if score > best {
+			best, top = score, candidate
+		}
+	}
+
+	if top == nil {
+		// Pages with a single text block still mark it up as an article
+		if article := doc.Find("article, main, [itemprop=articleBody]").First(); article.Length() > 0 {
+			return article
+		}
+		return nil
+	}
+
+	// Walk up while the parent holds most of the content, e.g. when the text
+	// is split into sibling sections
+	for parent := top.Parent(); parent.Length() > 0 && goquery.NodeName(parent) != "body" && goquery.NodeName(parent) != "html"; parent = parent.Parent() {
+		if score, ok := scores[parent.Get(0)]; !ok || score < best*0.75 {
+			break
+		}
+		top = parent
+	}
+
+	return top
+}
+
+// initialScore scores an element by its tag and by its class name and ID
+func initialScore(s *goquery.Selection) float64 {
+	var score float64
+	switch goquery.NodeName(s) {
+	case "article":
+		score = 10
+	case "div", "main", "section":
+		score = 5
+	case "pre", "td", "blockquote":
+		score = 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score = -5
+	}
+
+	for _, attr := range []string{s.AttrOr("class", ""), s.AttrOr("id", "")} {
+		if attr == "" {
+			continue
+		}
+		if negativeWeight.MatchString(attr) {
+			score -= 25
+		}
+		if positiveWeight.MatchString(attr) {
+			score += 25
+		}
+	}
+	return score
+}
+
+// linkDensity returns the share of the text of an element that is link text
+func linkDensity(s *goquery.Selection) float64 {
+	textLength := len(strings.TrimSpace(s.Text()))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		linkLength += len(strings.TrimSpace(a.Text()))
+	})
+	return float64(linkLength) / float64(textLength)
+}
+
+// collectContent returns the top candidate together with sibling paragraphs
+// that belong to the article
+func collectContent(top *goquery.Selection) *goquery.Selection {
+	parent := top.Parent()
+	if parent.Length() == 0 || goquery.NodeName(parent) == "body" {
+		return top
+	}
+
+	wrapper := goquery.NewDocumentFromNode(&html.Node{Type: html.ElementNode, Data: "div"}).Selection
+	parent.Children().Each(func(_ int, sibling *goquery.Selection) {
+		if sibling.Get(0) == top.Get(0) {
+			wrapper.AppendSelection(sibling.Clone())
+			return
+		}
+		if goquery.NodeName(sibling) != "p" {
+			return
+		}
+		text := strings.TrimSpace(sibling.Text())
+		density := linkDensity(sibling)
+		if (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.HasSuffix(text, ".")) {
+			wrapper.AppendSelection(sibling.Clone())
+		}
+	})
+	return wrapper
+}
+
+// cleanContent strips presentational attributes, drops empty containers and
+// resolves URLs so the content can be embedded in a feed
+func cleanContent(content *goquery.Selection, base *url.URL) {
+	// Lazy-loaded images keep the real source in a data attribute
+	content.Find("img").Each(func(_ int, img *goquery.Selection) {
+		for _, attr := range []string{"data-src", "data-original", "data-lazy-src"} {
+			if src := img.AttrOr(attr, ""); src != "" {
+				img.SetAttr("src", src)
+				break
+			}
+		}
+		if srcset := img.AttrOr("data-srcset", ""); srcset != "" {
+			img.SetAttr("srcset", srcset)
+		}
+	})
+
+	content.Find("*").AddSelection(content).Each(func(_ int, s *goquery.Selection) {
+		node := s.Get(0)
+		attrs := node.Attr[:0]
+		for _, attr := range node.Attr {
+			if keptAttributes[attr.Key] {
+				attrs = append(attrs, attr)
+			}
+		}
+		node.Attr = attrs
+	})
+
+	// Drop leftover link lists and empty blocks
+	content.Find("div, section, ul, ol, table").Each(func(_ int, s *goquery.Selection) {
+		if s.Find("img, video, audio, picture, pre").Length() == 0 && linkDensity(s) > 0.5 {
+			s.Remove()
+		}
+	})
+	content.Find("p, div, section, span").Each(func(_ int, s *goquery.Selection) {
+		if strings.TrimSpace(s.Text()) == "" && s.Find("img, video, audio, picture, iframe").Length() == 0 {
+			s.Remove()
+		}
+	})
+
+	if base == nil {
+		return
+	}
+	resolve := func(s *goquery.Selection, attr string) {
+		if value, ok := s.Attr(attr); ok {
+			if ref, err := url.Parse(strings.TrimSpace(value)); err == nil {
+				s.SetAttr(attr, base.ResolveReference(ref).String())
+			}
+		}
+	}
+	content.Find("a[href]").Each(func(_ int, s *goquery.Selection) { resolve(s, "href") })
+	content.Find("img[src], video[src], audio[src], source[src]").Each(func(_ int, s *goquery.Selection) { resolve(s, "src") })
+}
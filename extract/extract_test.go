@@ -0,0 +1,90 @@
+package extract
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Fallback Title</title>
+  <meta property="og:title" content="Introducing Widgets">
+  <meta name="author" content="Jane Doe">
+  <meta name="description" content="All about widgets.">
+  <script>var tracking = true;</script>
+</head>
+<body>
+  <header class="site-header"><a href="/">Home</a> <a href="/news">News</a></header>
+  <nav><ul><li><a href="/a">A</a></li><li><a href="/b">B</a></li></ul></nav>
+  <div class="layout">
+    <div class="sidebar">
+      <p>Subscribe to our newsletter, follow us, share this page with your friends and family.</p>
+    </div>
+    <div class="post-body" style="color: red">
+      <h1>Introducing Widgets</h1>
+      <p>Widgets are small, composable building blocks that make it easier to build interfaces, and they are now available to everyone.</p>
+      <p>Each widget renders itself, handles its own events, and can be combined with other widgets to form larger components.</p>
+      <img data-src="/images/widget.png" src="data:image/gif;base64,R0lGOD" alt="A widget">
+      <p>Read the <a href="/docs/widgets" onclick="track()">documentation</a> to get started, or browse the gallery of examples.</p>
+      <div class="share-buttons"><a href="https://twitter.com/share">Tweet</a> <a href="https://facebook.com/share">Share</a></div>
+    </div>
+  </div>
+  <footer><p>Copyright 2024, Example Inc. All rights reserved worldwide.</p></footer>
+</body>
+</html>`
+
+func TestExtract(t *testing.T) {
+	article, err := Extract([]byte(articlePage), "https://example.com/news/widgets")
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	if article.Title != "Introducing Widgets" {
+		t.Errorf("Expected og:title, got %q", article.Title)
+	}
+	if article.Byline != "Jane Doe" || article.Excerpt != "All about widgets." {
+		t.Errorf("Wrong metadata: %q / %q", article.Byline, article.Excerpt)
+	}
+
+	expected := []string{
+		"Widgets are small, composable building blocks",
+		"can be combined with other widgets",
+		`<img src="https://example.com/images/widget.png" alt="A widget"/>`,
+		`<a href="https://example.com/docs/widgets">documentation</a>`,
+	}
+	for _, s := range expected {
+		if !strings.Contains(article.Content, s) {
+			t.Errorf("Expected content to contain %q, got %s", s, article.Content)
+		}
+	}
+
+	unexpected := []string{"newsletter", "Copyright", "Tweet", "Home", "tracking", "style=", "onclick", "class="}
+	for _, s := range unexpected {
+		if strings.Contains(article.Content, s) {
+			t.Errorf("Expected content not to contain %q, got %s", s, article.Content)
+		}
+	}
+}
+
+func TestExtract_NoContent(t *testing.T) {
+	page := `<html><head><title>Empty</title></head><body><nav><a href="/">Home</a></nav><p>Short.</p></body></html>`
+
+	if _, err := Extract([]byte(page), "https://example.com/"); !errors.Is(err, ErrNoContent) {
+		t.Errorf("Expected ErrNoContent, got %v", err)
+	}
+}
+
+func TestExtract_Charset(t *testing.T) {
+	page := "<html><head><meta charset=\"iso-8859-1\"></head><body><article><p>" +
+		strings.Repeat("Caf\xe9 cr\xe8me, ", 20) + "</p></article></body></html>"
+
+	article, err := Extract([]byte(page), "")
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if !strings.Contains(article.Content, "Café crème") {
+		t.Errorf("Expected decoded Latin-1 content, got %s", article.Content)
+	}
+}
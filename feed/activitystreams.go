@@ -0,0 +1,203 @@
+package feed
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ActivityStreams 2.0 context URI
+const activityStreamsContext = "https://www.w3.org/ns/activitystreams"
+
+// ASCollection represents an ActivityStreams 2.0 OrderedCollection
+type ASCollection struct {
+	Context      string       `json:"@context"`
+	ID           string       `json:"id"`
+	Type         string       `json:"type"`
+	Name         string       `json:"name"`
+	Summary      string       `json:"summary,omitempty"`
+	URL          string       `json:"url,omitempty"`
+	Updated      string       `json:"updated,omitempty"`
+	TotalItems   int          `json:"totalItems"`
+	OrderedItems []ASActivity `json:"orderedItems"`
+}
+
+// ASActivity represents a Create activity
+type ASActivity struct {
+	ID        string   `json:"id,omitempty"`
+	Type      string   `json:"type"`
+	Actor     *ASActor `json:"actor"`
+	Published string   `json:"published,omitempty"`
+	Object    ASNote   `json:"object"`
+}
+
+// ASActor represents the actor or author of an activity
+type ASActor struct {
+	Type string   `json:"type"`
+	Name string   `json:"name"`
+	URL  string   `json:"url,omitempty"`
+	Icon *ASImage `json:"icon,omitempty"`
+}
+
+// ASImage represents an Image object such as an actor avatar
+type ASImage struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// ASNote represents a Note object
+type ASNote struct {
+	ID           string         `json:"id,omitempty"`
+	Type         string         `json:"type"`
+	Name         string         `json:"name,omitempty"`
+	Summary      string         `json:"summary,omitempty"`
+	Content      string         `json:"content,omitempty"`
+	MediaType    string         `json:"mediaType,omitempty"`
+	URL          string         `json:"url,omitempty"`
+	Published    string         `json:"published,omitempty"`
+	Updated      string         `json:"updated,omitempty"`
+	AttributedTo *ASActor       `json:"attributedTo,omitempty"`
+	Tag          []ASTag        `json:"tag,omitempty"`
+	Attachment   []ASAttachment `json:"attachment,omitempty"`
+}
+
+// ASTag represents a Hashtag
+type ASTag struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// ASAttachment represents a linked Document such as an image or enclosure
+type ASAttachment struct {
+	Type      string `json:"type"`
+	MediaType string `json:"mediaType,omitempty"`
+	URL       string `json:"url"`
+	Name      string `json:"name,omitempty"`
+}
+
+func init() {
+	RegisterFormat(Format{
+		Name:        "activitystreams",
+		ContentType: "application/activity+json; charset=utf-8",
+		MediaTypes:  []string{"application/ld+json"},
+		Generate:    GenerateActivityStreams,
+	})
+}
+
+// GenerateActivityStreams converts Data to an ActivityStreams 2.0 OrderedCollection
+// of Create activities wrapping Note objects
+func GenerateActivityStreams(data *Data, currentURL string) (string, error) {
+	collection := ASCollection{
+		Context:    activityStreamsContext,
+		ID:         currentURL,
+		Type:       "OrderedCollection",
+		Name:       data.Title,
+		Summary:    data.Description,
+		URL:        data.Link,
+		TotalItems: len(data.Item),
+	}
+
+	// Set date
+	if !data.LastBuildDate.IsZero() {
+		collection.Updated = formatRFC3339(data.LastBuildDate)
+	} else if !data.PubDate.IsZero() {
+		collection.Updated = formatRFC3339(data.PubDate)
+	}
+
+	// Activities without an item author are attributed to the feed itself
+	feedActor := &ASActor{Type: "Service", Name: data.Title, URL: data.Link}
+	if authors := data.AllAuthors(); len(authors) > 0 {
+		feedActor = newASActor(authors[0])
+	}
+
+	// Convert items
+	collection.OrderedItems = make([]ASActivity, len(data.Item))
+	for i, item := range data.Item {
+		note := ASNote{
+			ID:      item.Link,
+			Type:    "Note",
+			Name:    item.Title,
+			Summary: item.Summary,
+			Content: item.HTML(),
+			URL:     item.Link,
+		}
+
+		// Set ID from GUID if no link is provided
+		if note.ID == "" {
+			note.ID = item.GUID
+		}
+		if note.Content != "" {
+			note.MediaType = "text/html"
+		}
+
+		// Set dates
+		if !item.PubDate.IsZero() {
+			note.Published = formatRFC3339(item.PubDate)
+		}
+		if !item.Updated.IsZero() {
+			note.Updated = formatRFC3339(item.Updated)
+		}
+
+		// Set author, ActivityStreams activities have a single actor
+		actor := feedActor
+		if authors := item.AllAuthors(); len(authors) > 0 {
+			actor = newASActor(authors[0])
+		}
+		note.AttributedTo = actor
+
+		// Set tags
+		for _, category := range item.Category {
+			if name := strings.Join(strings.Fields(category), ""); name != "" {
+				note.Tag = append(note.Tag, ASTag{Type: "Hashtag", Name: "#" + name})
+			}
+		}
+
+		// Set attachments
+		attachments := item.AllAttachments()
+		for _, attachment := range attachments {
+			note.Attachment = append(note.Attachment, ASAttachment{
+				Type:      "Document",
+				MediaType: attachment.Type,
+				URL:       attachment.URL,
+				Name:      attachment.Title,
+			})
+		}
+		if item.Media != nil {
+			if image := mediaImage(item.Media); image != "" && !hasAttachment(attachments, image) {
+				note.Attachment = append(note.Attachment, ASAttachment{
+					Type: "Image",
+					URL:  image,
+					Name: item.Media.Title,
+				})
+			}
+		}
+
+		activity := ASActivity{
+			Type:      "Create",
+			Actor:     actor,
+			Published: note.Published,
+			Object:    note,
+		}
+		if note.ID != "" {
+			activity.ID = note.ID + "#create"
+		}
+
+		collection.OrderedItems[i] = activity
+	}
+
+	// Marshal to JSON
+	output, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
+
+// newASActor converts a person to an ActivityStreams Person actor
+func newASActor(person Person) *ASActor {
+	actor := &ASActor{Type: "Person", Name: person.DisplayName(), URL: person.URL}
+	if person.Avatar != "" {
+		actor.Icon = &ASImage{Type: "Image", URL: person.Avatar}
+	}
+	return actor
+}
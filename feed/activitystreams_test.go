@@ -0,0 +1,71 @@
+package feed
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestGenerateActivityStreams(t *testing.T) {
+	data := &Data{
+		Title: "Test Feed",
+		Link:  "https://example.com",
+		Item: []Item{
+			{
+				Title:       "Item 1",
+				Link:        "https://example.com/item1",
+				Description: "<p>Hello</p>",
+				Author:      "Jane",
+				Category:    []string{"open source"},
+				PubDate:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			},
+			{
+				Title: "Item 2",
+				GUID:  "tag:example.com,2024:2",
+			},
+		},
+	}
+
+	output, err := GenerateActivityStreams(data, "https://example.com/feed?format=activitystreams")
+	if err != nil {
+		t.Fatalf("GenerateActivityStreams failed: %v", err)
+	}
+
+	var collection ASCollection
+	if err := json.Unmarshal([]byte(output), &collection); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	if collection.Context != "https://www.w3.org/ns/activitystreams" || collection.Type != "OrderedCollection" {
+		t.Errorf("Wrong collection: %s / %s", collection.Context, collection.Type)
+	}
+	if collection.TotalItems != 2 || len(collection.OrderedItems) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(collection.OrderedItems))
+	}
+
+	activity := collection.OrderedItems[0]
+	if activity.Type != "Create" || activity.Object.Type != "Note" {
+		t.Errorf("Expected Create/Note, got %s/%s", activity.Type, activity.Object.Type)
+	}
+	if activity.ID != "https://example.com/item1#create" {
+		t.Errorf("Wrong activity ID: %s", activity.ID)
+	}
+	if activity.Actor.Type != "Person" || activity.Actor.Name != "Jane" {
+		t.Errorf("Wrong actor: %+v", activity.Actor)
+	}
+	if activity.Published != "2024-01-01T12:00:00Z" || activity.Object.MediaType != "text/html" {
+		t.Errorf("Wrong note: %+v", activity.Object)
+	}
+	if len(activity.Object.Tag) != 1 || activity.Object.Tag[0].Name != "#opensource" {
+		t.Errorf("Wrong tags: %+v", activity.Object.Tag)
+	}
+
+	// Items without a link fall back to the GUID and the feed as actor
+	activity = collection.OrderedItems[1]
+	if activity.Object.ID != "tag:example.com,2024:2" {
+		t.Errorf("Expected GUID as ID, got %s", activity.Object.ID)
+	}
+	if activity.Actor.Type != "Service" || activity.Actor.Name != "Test Feed" {
+		t.Errorf("Expected feed as actor, got %+v", activity.Actor)
+	}
+}
@@ -7,41 +7,48 @@ import (
 
 // AtomFeed represents an Atom 1.0 feed
 type AtomFeed struct {
-	XMLName xml.Name    `xml:"feed"`
-	Xmlns   string      `xml:"xmlns,attr"`
-	Title   string      `xml:"title"`
-	Link    []AtomFeedLink `xml:"link"`
-	Updated string      `xml:"updated"`
-	ID      string      `xml:"id"`
-	Subtitle string     `xml:"subtitle,omitempty"`
-	Icon    string      `xml:"icon,omitempty"`
-	Logo    string      `xml:"logo,omitempty"`
-	Author  *AtomAuthor `xml:"author,omitempty"`
-	Entries []AtomEntry `xml:"entry"`
+	XMLName  xml.Name       `xml:"feed"`
+	Xmlns    string         `xml:"xmlns,attr"`
+	MediaNS  string         `xml:"xmlns:media,attr,omitempty"`
+	Title    string         `xml:"title"`
+	Link     []AtomFeedLink `xml:"link"`
+	Updated  string         `xml:"updated"`
+	ID       string         `xml:"id"`
+	Subtitle string         `xml:"subtitle,omitempty"`
+	Icon     string         `xml:"icon,omitempty"`
+	Logo     string         `xml:"logo,omitempty"`
+	Author   []AtomAuthor   `xml:"author,omitempty"`
+	Entries  []AtomEntry    `xml:"entry"`
 }
 
 // AtomFeedLink represents a link in the Atom feed
 type AtomFeedLink struct {
-	Href string `xml:"href,attr"`
-	Rel  string `xml:"rel,attr,omitempty"`
-	Type string `xml:"type,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Title  string `xml:"title,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
 }
 
-// AtomAuthor represents the author of the feed
+// AtomAuthor represents an Atom person construct
 type AtomAuthor struct {
-	Name string `xml:"name"`
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+	URI   string `xml:"uri,omitempty"`
 }
 
 // AtomEntry represents a single Atom entry
 type AtomEntry struct {
-	Title   string           `xml:"title"`
-	Link    []AtomFeedLink   `xml:"link"`
-	ID      string           `xml:"id"`
-	Updated string           `xml:"updated"`
-	Summary *AtomText        `xml:"summary,omitempty"`
-	Content *AtomContent     `xml:"content,omitempty"`
-	Author  *AtomAuthor      `xml:"author,omitempty"`
-	Category []AtomCategory  `xml:"category,omitempty"`
+	Title     string         `xml:"title"`
+	Link      []AtomFeedLink `xml:"link"`
+	ID        string         `xml:"id"`
+	Published string         `xml:"published,omitempty"`
+	Updated   string         `xml:"updated"`
+	Summary   *AtomText      `xml:"summary,omitempty"`
+	Content   *AtomContent   `xml:"content,omitempty"`
+	Author    []AtomAuthor   `xml:"author,omitempty"`
+	Category  []AtomCategory `xml:"category,omitempty"`
+	MediaElements
 }
 
 // AtomText represents text content
@@ -61,6 +68,16 @@ type AtomCategory struct {
 	Term string `xml:"term,attr"`
 }
 
+func init() {
+	RegisterFormat(Format{
+		Name:        "atom",
+		ContentType: "application/atom+xml; charset=utf-8",
+		Extension:   "atom",
+		Stylesheet:  true,
+		Generate:    GenerateAtom,
+	})
+}
+
 // GenerateAtom converts Data to Atom 1.0 XML format
 func GenerateAtom(data *Data, currentURL string) (string, error) {
 	atom := AtomFeed{
@@ -83,9 +100,12 @@ func GenerateAtom(data *Data, currentURL string) (string, error) {
 		atom.Updated = formatRFC3339(time.Now())
 	}
 
-	// Set author
-	if data.Author != "" {
-		atom.Author = &AtomAuthor{Name: data.Author}
+	// Set authors
+	atom.Author = atomAuthors(data.AllAuthors())
+
+	// Declare the Media RSS namespace only when it is used
+	if hasMedia(data.Item) {
+		atom.MediaNS = mediaNamespace
 	}
 
 	// Convert items
@@ -99,12 +119,45 @@ func GenerateAtom(data *Data, currentURL string) (string, error) {
 			ID: item.GUID,
 		}
 
+		// Set enclosure and typed links
+		attachments := item.AllAttachments()
+		for _, attachment := range attachments {
+			entry.Link = append(entry.Link, AtomFeedLink{
+				Href:   attachment.URL,
+				Rel:    "enclosure",
+				Type:   attachment.Type,
+				Title:  attachment.Title,
+				Length: attachment.Length,
+			})
+		}
+		for _, link := range item.Links {
+			entry.Link = append(entry.Link, AtomFeedLink{
+				Href:  link.Href,
+				Rel:   link.Rel,
+				Type:  link.Type,
+				Title: link.Title,
+			})
+		}
+
+		// Expose torrent as an enclosure link
+		if item.Torrent != nil && item.Torrent.Link != "" && !hasAttachment(attachments, item.Torrent.Link) {
+			entry.Link = append(entry.Link, AtomFeedLink{
+				Href:   item.Torrent.Link,
+				Rel:    "enclosure",
+				Type:   torrentMIMEType,
+				Length: item.Torrent.ContentLength,
+			})
+		}
+
 		// Set ID from link if not provided
 		if entry.ID == "" {
 			entry.ID = item.Link
 		}
 
-		// Set updated time
+		// Set dates
+		if !item.PubDate.IsZero() {
+			entry.Published = formatRFC3339(item.PubDate)
+		}
 		if !item.Updated.IsZero() {
 			entry.Updated = formatRFC3339(item.Updated)
 		} else if !item.PubDate.IsZero() {
@@ -113,18 +166,24 @@ func GenerateAtom(data *Data, currentURL string) (string, error) {
 			entry.Updated = formatRFC3339(time.Now())
 		}
 
-		// Set content
-		if item.Description != "" {
+		// Set summary and content
+		if summary := item.SummaryText(); summary != "" {
+			entry.Summary = &AtomText{Type: "text", Value: summary}
+		}
+		if item.ContentHTML != "" || item.Description != "" {
 			entry.Content = &AtomContent{
 				Type:  "html",
-				Value: item.Description,
+				Value: item.HTML(),
+			}
+		} else if item.ContentText != "" {
+			entry.Content = &AtomContent{
+				Type:  "text",
+				Value: item.ContentText,
 			}
 		}
 
-		// Set author
-		if item.Author != "" {
-			entry.Author = &AtomAuthor{Name: item.Author}
-		}
+		// Set authors
+		entry.Author = atomAuthors(item.AllAuthors())
 
 		// Set categories
 		if len(item.Category) > 0 {
@@ -134,6 +193,9 @@ func GenerateAtom(data *Data, currentURL string) (string, error) {
 			}
 		}
 
+		// Set Media RSS elements
+		entry.MediaElements = buildMediaElements(item.Media)
+
 		atom.Entries[i] = entry
 	}
 
@@ -143,10 +205,22 @@ func GenerateAtom(data *Data, currentURL string) (string, error) {
 		return "", err
 	}
 
-	return xml.Header + string(output), nil
+	return xml.Header + stylesheetInstruction(data.Stylesheet) + string(output), nil
+}
+
+// atomAuthors converts people to Atom person constructs, which require a name
+func atomAuthors(people []Person) []AtomAuthor {
+	var authors []AtomAuthor
+	for _, person := range people {
+		if name := person.DisplayName(); name != "" {
+			authors = append(authors, AtomAuthor{Name: name, Email: person.Email, URI: person.URL})
+		}
+	}
+	return authors
 }
 
-// formatRFC3339 formats a time.Time to RFC3339 format (Atom date format)
+// formatRFC3339 formats a time.Time to RFC3339 format (Atom date format) in
+// its own timezone
 func formatRFC3339(t time.Time) string {
-	return t.UTC().Format(time.RFC3339)
+	return t.Format(time.RFC3339)
 }
@@ -61,7 +61,7 @@ func TestGenerateAtom(t *testing.T) {
 	if atom.Icon != "https://example.com/icon.png" {
 		t.Errorf("Wrong icon: %s", atom.Icon)
 	}
-	if atom.Author == nil || atom.Author.Name != "Jane Doe" {
+	if len(atom.Author) != 1 || atom.Author[0].Name != "Jane Doe" {
 		t.Error("Expected author 'Jane Doe'")
 	}
 	if len(atom.Entries) != 1 {
@@ -76,7 +76,7 @@ func TestGenerateAtom(t *testing.T) {
 	if entry.ID != "item1" {
 		t.Errorf("Expected ID 'item1', got '%s'", entry.ID)
 	}
-	if entry.Author == nil || entry.Author.Name != "John Doe" {
+	if len(entry.Author) != 1 || entry.Author[0].Name != "John Doe" {
 		t.Error("Expected author 'John Doe'")
 	}
 	if len(entry.Category) != 2 {
@@ -159,6 +159,56 @@ func TestGenerateAtom_DefaultID(t *testing.T) {
 	}
 }
 
+func TestGenerateAtom_WithMedia(t *testing.T) {
+	data := &Data{
+		Title: "Feed with Media",
+		Link:  "https://example.com",
+		Item: []Item{
+			{
+				Title: "Video",
+				Link:  "https://example.com/video",
+				Media: &Media{
+					Thumbnail:   &MediaThumbnail{URL: "https://example.com/thumb.jpg"},
+					Description: "A short clip",
+				},
+			},
+		},
+	}
+
+	output, err := GenerateAtom(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateAtom failed: %v", err)
+	}
+
+	expected := []string{
+		`xmlns:media="http://search.yahoo.com/mrss/"`,
+		`<media:thumbnail url="https://example.com/thumb.jpg">`,
+		`<media:description type="plain">A short clip</media:description>`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %s", want)
+		}
+	}
+}
+
+func TestGenerateAtom_WithoutMedia(t *testing.T) {
+	data := &Data{
+		Title: "Feed without Media",
+		Link:  "https://example.com",
+		Item:  []Item{{Title: "Item", Link: "https://example.com/item"}},
+	}
+
+	output, err := GenerateAtom(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateAtom failed: %v", err)
+	}
+
+	if strings.Contains(output, "xmlns:media") {
+		t.Error("Media namespace should only be declared when items carry media")
+	}
+}
+
 func TestFormatRFC3339(t *testing.T) {
 	// Test RFC3339 date formatting
 	testTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
@@ -170,3 +220,90 @@ func TestFormatRFC3339(t *testing.T) {
 		t.Errorf("Expected '%s', got '%s'", expected, formatted)
 	}
 }
+
+func TestGenerateAtom_SummaryAndContent(t *testing.T) {
+	data := &Data{
+		Title: "Test Feed",
+		Link:  "https://example.com",
+		Item: []Item{
+			{Title: "HTML", Summary: "Short summary", ContentHTML: "<p>Full content</p>"},
+			{Title: "Text", ContentText: "Plain content"},
+		},
+	}
+
+	output, err := GenerateAtom(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateAtom failed: %v", err)
+	}
+
+	var atom AtomFeed
+	if err := xml.Unmarshal([]byte(output), &atom); err != nil {
+		t.Fatalf("Invalid XML: %v", err)
+	}
+
+	entry := atom.Entries[0]
+	if entry.Summary == nil || entry.Summary.Value != "Short summary" || entry.Summary.Type != "text" {
+		t.Errorf("Wrong summary: %+v", entry.Summary)
+	}
+	if entry.Content == nil || entry.Content.Type != "html" || entry.Content.Value != "<p>Full content</p>" {
+		t.Errorf("Wrong content: %+v", entry.Content)
+	}
+
+	entry = atom.Entries[1]
+	if entry.Content == nil || entry.Content.Type != "text" || entry.Content.Value != "Plain content" {
+		t.Errorf("Expected text content, got %+v", entry.Content)
+	}
+}
+
+func TestGenerateAtom_AuthorsAndLinks(t *testing.T) {
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := &Data{
+		Title: "Test Feed",
+		Link:  "https://example.com",
+		Item: []Item{
+			{
+				Title:   "Item",
+				Link:    "https://example.com/item",
+				PubDate: published,
+				Author:  "Legacy",
+				Authors: []Person{{Name: "Jane Doe", Email: "jane@example.com", URL: "https://example.com/jane"}},
+				Attachments: []Attachment{
+					{URL: "https://example.com/a.mp3", Type: "audio/mpeg", Length: 100, Title: "Episode"},
+				},
+				Links: []Link{{Href: "https://example.org/source", Rel: "via"}},
+			},
+		},
+	}
+
+	output, err := GenerateAtom(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateAtom failed: %v", err)
+	}
+
+	var atom AtomFeed
+	if err := xml.Unmarshal([]byte(output), &atom); err != nil {
+		t.Fatalf("Invalid XML: %v", err)
+	}
+
+	entry := atom.Entries[0]
+	if entry.Published != "2024-01-01T00:00:00Z" {
+		t.Errorf("Wrong published date: %s", entry.Published)
+	}
+	if len(entry.Author) != 2 || entry.Author[0].Name != "Legacy" {
+		t.Fatalf("Expected shorthand and structured authors, got %+v", entry.Author)
+	}
+	if author := entry.Author[1]; author.Email != "jane@example.com" || author.URI != "https://example.com/jane" {
+		t.Errorf("Wrong author: %+v", author)
+	}
+
+	links := map[string]AtomFeedLink{}
+	for _, link := range entry.Link {
+		links[link.Rel] = link
+	}
+	if enclosure := links["enclosure"]; enclosure.Title != "Episode" || enclosure.Length != 100 {
+		t.Errorf("Wrong enclosure link: %+v", enclosure)
+	}
+	if links["via"].Href != "https://example.org/source" {
+		t.Errorf("Missing via link: %+v", entry.Link)
+	}
+}
@@ -0,0 +1,114 @@
+package feed
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// AllAuthors returns the feed authors, including the Author shorthand
+func (data *Data) AllAuthors() []Person {
+	return mergeAuthors(data.Author, data.Authors)
+}
+
+// AllAuthors returns the item authors, including the Author shorthand
+func (item *Item) AllAuthors() []Person {
+	return mergeAuthors(item.Author, item.Authors)
+}
+
+// AllAttachments returns the item attachments, starting with the Enclosure
+// shorthand when it is set
+func (item *Item) AllAttachments() []Attachment {
+	if item.EnclosureURL == "" {
+		return item.Attachments
+	}
+
+	attachments := []Attachment{{
+		URL:    item.EnclosureURL,
+		Type:   item.EnclosureType,
+		Length: item.EnclosureLength,
+	}}
+	for _, attachment := range item.Attachments {
+		if attachment.URL != item.EnclosureURL {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments
+}
+
+// DisplayName returns the name of the person, falling back to the email
+// address and URL
+func (p Person) DisplayName() string {
+	return firstNonEmpty(p.Name, p.Email, p.URL)
+}
+
+// mergeAuthors prepends the author shorthand to the structured authors unless
+// it is already listed
+func mergeAuthors(name string, authors []Person) []Person {
+	if name == "" {
+		return authors
+	}
+	for _, author := range authors {
+		if author.Name == name {
+			return authors
+		}
+	}
+	return append([]Person{{Name: name}}, authors...)
+}
+
+// authorNames joins the display names of people into a single string
+func authorNames(people []Person) string {
+	names := make([]string, 0, len(people))
+	for _, person := range people {
+		if name := person.DisplayName(); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// hasAttachment reports whether an attachment with the given URL exists
+func hasAttachment(attachments []Attachment, url string) bool {
+	for _, attachment := range attachments {
+		if attachment.URL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// formatRSSPerson formats a person in the RSS "email (Name)" convention
+func formatRSSPerson(p Person) string {
+	if p.Name == "" {
+		return p.Email
+	}
+	return p.Email + " (" + p.Name + ")"
+}
+
+// parsePerson parses an RSS or email style author such as "email (Name)" or
+// "Name <email>", keeping anything else as the name
+func parsePerson(s string) Person {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Person{}
+	}
+
+	// email (Name)
+	if open := strings.Index(s, " ("); open > 0 && strings.HasSuffix(s, ")") {
+		if email := s[:open]; isEmail(email) {
+			return Person{Name: strings.TrimSpace(s[open+2 : len(s)-1]), Email: email}
+		}
+	}
+
+	// Name <email> or a bare address
+	if address, err := mail.ParseAddress(s); err == nil {
+		return Person{Name: address.Name, Email: address.Address}
+	}
+
+	return Person{Name: s}
+}
+
+// isEmail reports whether s looks like a bare email address
+func isEmail(s string) bool {
+	at := strings.Index(s, "@")
+	return at > 0 && at < len(s)-1 && !strings.ContainsAny(s, " <>()")
+}
@@ -0,0 +1,46 @@
+package feed
+
+import "testing"
+
+func TestParsePerson(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Person
+	}{
+		{"", Person{}},
+		{"Jane Doe", Person{Name: "Jane Doe"}},
+		{"jane@example.com", Person{Email: "jane@example.com"}},
+		{"jane@example.com (Jane Doe)", Person{Name: "Jane Doe", Email: "jane@example.com"}},
+		{"Jane Doe <jane@example.com>", Person{Name: "Jane Doe", Email: "jane@example.com"}},
+		{"Jane (Editor)", Person{Name: "Jane (Editor)"}},
+	}
+
+	for _, test := range tests {
+		if result := parsePerson(test.input); result != test.expected {
+			t.Errorf("parsePerson(%q) = %+v, expected %+v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestItemAllAuthorsAndAttachments(t *testing.T) {
+	item := Item{
+		Author:       "Jane",
+		Authors:      []Person{{Name: "Jane", Email: "jane@example.com"}, {Name: "John"}},
+		EnclosureURL: "https://example.com/a.mp3",
+		Attachments:  []Attachment{{URL: "https://example.com/b.mp3"}, {URL: "https://example.com/a.mp3", Title: "Duplicate"}},
+	}
+
+	if authors := item.AllAuthors(); len(authors) != 2 || authors[0].Email != "jane@example.com" {
+		t.Errorf("Expected shorthand to be merged into authors, got %+v", authors)
+	}
+
+	attachments := item.AllAttachments()
+	if len(attachments) != 2 || attachments[0].URL != "https://example.com/a.mp3" || attachments[1].URL != "https://example.com/b.mp3" {
+		t.Errorf("Expected enclosure first without duplicates, got %+v", attachments)
+	}
+
+	legacy := Item{Author: "Jane"}
+	if authorNames(legacy.AllAuthors()) != "Jane" {
+		t.Errorf("Expected shorthand author, got %+v", legacy.AllAuthors())
+	}
+}
@@ -0,0 +1,67 @@
+package feed
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jean-jacket/grss/utils"
+)
+
+// summaryLength is the maximum length in runes of a derived summary
+const summaryLength = 300
+
+// HTML returns the full content of the item as HTML, falling back to the
+// legacy Description and to the escaped ContentText
+func (item *Item) HTML() string {
+	if item.ContentHTML != "" {
+		return item.ContentHTML
+	}
+	if item.Description != "" {
+		return item.Description
+	}
+	if item.ContentText != "" {
+		return strings.ReplaceAll(html.EscapeString(item.ContentText), "\n", "<br>\n")
+	}
+	return ""
+}
+
+// Text returns the full content of the item as plain text, deriving it from
+// the HTML content when ContentText is not set
+func (item *Item) Text() string {
+	if item.ContentText != "" {
+		return item.ContentText
+	}
+	return utils.StripHTML(item.HTML())
+}
+
+// SummaryText returns the plain-text summary of the item. When no Summary is
+// set, it is derived from the start of the content.
+func (item *Item) SummaryText() string {
+	if item.Summary != "" {
+		return item.Summary
+	}
+	return summarize(item.Text(), summaryLength)
+}
+
+// summarize shortens plain text to at most maxLen runes on a word boundary,
+// collapsing line breaks
+func summarize(text string, maxLen int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxLen {
+		return text
+	}
+
+	// Leave room for the ellipsis
+	runes := []rune(text)[:maxLen-1]
+	cut := string(runes)
+	if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
+
+// plainText converts HTML to single-line plain text
+func plainText(s string) string {
+	return strings.Join(strings.Fields(utils.StripHTML(s)), " ")
+}
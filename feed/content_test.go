@@ -0,0 +1,55 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestItemContent(t *testing.T) {
+	item := Item{ContentHTML: "<p>First paragraph</p><p>Second &amp; last</p><script>alert(1)</script>"}
+
+	if item.HTML() != item.ContentHTML {
+		t.Errorf("Expected ContentHTML, got %s", item.HTML())
+	}
+	if item.Text() != "First paragraph\n\nSecond & last" {
+		t.Errorf("Expected derived text, got %q", item.Text())
+	}
+	if item.SummaryText() != "First paragraph Second & last" {
+		t.Errorf("Expected derived summary, got %q", item.SummaryText())
+	}
+
+	// Explicit fields take precedence
+	item.Summary = "Summary"
+	item.ContentText = "Text"
+	if item.SummaryText() != "Summary" || item.Text() != "Text" {
+		t.Errorf("Expected explicit fields, got %q / %q", item.SummaryText(), item.Text())
+	}
+
+	// Legacy Description is HTML content
+	legacy := Item{Description: "<b>Legacy</b>"}
+	if legacy.HTML() != "<b>Legacy</b>" || legacy.SummaryText() != "Legacy" {
+		t.Errorf("Expected Description as HTML content, got %q / %q", legacy.HTML(), legacy.SummaryText())
+	}
+
+	// Text-only content is escaped for HTML outputs
+	text := Item{ContentText: "a < b\nc"}
+	if text.HTML() != "a &lt; b<br>\nc" {
+		t.Errorf("Expected escaped text, got %q", text.HTML())
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	summary := summarize(long, 50)
+	if utf8.RuneCountInString(summary) > 50 {
+		t.Errorf("Summary too long: %d runes", utf8.RuneCountInString(summary))
+	}
+	if !strings.HasSuffix(summary, "word…") {
+		t.Errorf("Expected cut on a word boundary with ellipsis, got %q", summary)
+	}
+
+	if summarize("short\n text", 50) != "short text" {
+		t.Error("Expected short text to be kept with collapsed whitespace")
+	}
+}
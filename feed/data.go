@@ -5,77 +5,187 @@ import "time"
 // Data represents the structured data returned by route handlers
 type Data struct {
 	// Feed metadata
-	Title       string    `json:"title"`
-	Link        string    `json:"link"`
-	Description string    `json:"description,omitempty"`
-	Language    string    `json:"language,omitempty"`
-	PubDate     time.Time `json:"pubDate,omitempty"`
+	Title         string    `json:"title"`
+	Link          string    `json:"link"`
+	Description   string    `json:"description,omitempty"`
+	Language      string    `json:"language,omitempty"`
+	PubDate       time.Time `json:"pubDate,omitempty"`
 	LastBuildDate time.Time `json:"lastBuildDate,omitempty"`
-	TTL         int       `json:"ttl,omitempty"`
-	AllowEmpty  bool      `json:"allowEmpty,omitempty"`
+	TTL           int       `json:"ttl,omitempty"`
+	AllowEmpty    bool      `json:"allowEmpty,omitempty"`
 
 	// Items
 	Item []Item `json:"item"`
 
-	// Optional metadata
-	Author   string `json:"author,omitempty"`
-	Image    string `json:"image,omitempty"`
-	Icon     string `json:"icon,omitempty"`
-	Logo     string `json:"logo,omitempty"`
-	Subtitle string `json:"subtitle,omitempty"`
+	// Optional metadata. Author is a shorthand for a single author name.
+	Author   string   `json:"author,omitempty"`
+	Authors  []Person `json:"authors,omitempty"`
+	Image    string   `json:"image,omitempty"`
+	Icon     string   `json:"icon,omitempty"`
+	Logo     string   `json:"logo,omitempty"`
+	Subtitle string   `json:"subtitle,omitempty"`
 
 	// iTunes podcast support
-	ItunesAuthor   string `json:"itunes_author,omitempty"`
-	ItunesCategory string `json:"itunes_category,omitempty"`
-	ItunesExplicit bool   `json:"itunes_explicit,omitempty"`
+	ItunesAuthor   string       `json:"itunes_author,omitempty"`
+	ItunesCategory string       `json:"itunes_category,omitempty"` // Subcategories separated by ">", e.g. "Technology > Tech News"
+	ItunesExplicit bool         `json:"itunes_explicit,omitempty"`
+	ItunesImage    string       `json:"itunes_image,omitempty"`
+	ItunesOwner    *ItunesOwner `json:"itunes_owner,omitempty"`
+
+	// Stylesheet is the URL of an XSLT stylesheet referenced by XML outputs
+	Stylesheet string `json:"-"`
 }
 
 // Item represents a single feed item
 type Item struct {
-	Title       string    `json:"title"`
-	Link        string    `json:"link"`
-	Description string    `json:"description,omitempty"`
-	PubDate     time.Time `json:"pubDate,omitempty"`
-	Updated     time.Time `json:"updated,omitempty"`
+	Title   string    `json:"title"`
+	Link    string    `json:"link"`
+	PubDate time.Time `json:"pubDate,omitempty"`
+	Updated time.Time `json:"updated,omitempty"`
+
+	// Content. Summary is plain text and is derived from the content when
+	// empty; ContentText is derived from ContentHTML when empty.
+	Summary     string `json:"summary,omitempty"`
+	ContentHTML string `json:"content_html,omitempty"`
+	ContentText string `json:"content_text,omitempty"`
 
-	// Optional fields
+	// Description is the HTML content of the item.
+	//
+	// Deprecated: set ContentHTML and Summary instead.
+	Description string `json:"description,omitempty"`
+
+	// Optional fields. Author is a shorthand for a single author name, use
+	// Authors for structured or multiple authors.
 	Author   string   `json:"author,omitempty"`
+	Authors  []Person `json:"authors,omitempty"`
 	Category []string `json:"category,omitempty"`
 	GUID     string   `json:"guid,omitempty"`
 	Comments string   `json:"comments,omitempty"`
 
-	// Enclosure (media)
-	EnclosureURL    string `json:"enclosure_url,omitempty"`
-	EnclosureType   string `json:"enclosure_type,omitempty"`
-	EnclosureLength int64  `json:"enclosure_length,omitempty"`
+	// Additional typed links, such as related, via or replies
+	Links []Link `json:"links,omitempty"`
+
+	// Enclosure (media). The Enclosure fields are a shorthand for a single
+	// attachment, use Attachments for titled or multiple attachments.
+	EnclosureURL    string       `json:"enclosure_url,omitempty"`
+	EnclosureType   string       `json:"enclosure_type,omitempty"`
+	EnclosureLength int64        `json:"enclosure_length,omitempty"`
+	Attachments     []Attachment `json:"attachments,omitempty"`
 
 	// Media RSS
 	Media *Media `json:"media,omitempty"`
 
 	// Torrent support
 	Torrent *Torrent `json:"torrent,omitempty"`
+
+	// iTunes podcast support
+	ItunesDuration int `json:"itunes_duration,omitempty"` // Seconds
+	ItunesEpisode  int `json:"itunes_episode,omitempty"`
+	ItunesSeason   int `json:"itunes_season,omitempty"`
+
+	// Podcasting 2.0 support
+	Transcripts []Transcript `json:"transcripts,omitempty"`
+	Chapters    *Chapters    `json:"chapters,omitempty"`
+
+	// Event metadata for calendar output, which starts at PubDate
+	Event *Event `json:"event,omitempty"`
+}
+
+// Person represents an author or contributor
+type Person struct {
+	Name   string `json:"name,omitempty"`
+	Email  string `json:"email,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+// Attachment represents an enclosed file such as a podcast episode
+type Attachment struct {
+	URL      string `json:"url"`
+	Type     string `json:"type,omitempty"`
+	Length   int64  `json:"length,omitempty"` // Bytes
+	Title    string `json:"title,omitempty"`
+	Duration int    `json:"duration,omitempty"` // Seconds
+}
+
+// Link represents a typed link to a related resource
+type Link struct {
+	Href  string `json:"href"`
+	Rel   string `json:"rel,omitempty"` // related, via, replies, ...
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
 }
 
 // Media represents media RSS content
 type Media struct {
-	Content   *MediaContent   `json:"content,omitempty"`
-	Thumbnail *MediaThumbnail `json:"thumbnail,omitempty"`
+	Content     *MediaContent   `json:"content,omitempty"`
+	Player      *MediaPlayer    `json:"player,omitempty"`
+	Thumbnail   *MediaThumbnail `json:"thumbnail,omitempty"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Credit      string          `json:"credit,omitempty"`
 }
 
 // MediaContent represents media content
 type MediaContent struct {
-	URL  string `json:"url"`
-	Type string `json:"type,omitempty"`
+	URL      string `json:"url"`
+	Type     string `json:"type,omitempty"`
+	Medium   string `json:"medium,omitempty"` // image, audio, video, document or executable
+	FileSize int64  `json:"fileSize,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Duration int    `json:"duration,omitempty"` // Seconds
+}
+
+// MediaPlayer represents a web page that plays the media, such as an embed
+// page. Unlike MediaContent, it is not a media file readers can download.
+type MediaPlayer struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
 }
 
 // MediaThumbnail represents media thumbnail
 type MediaThumbnail struct {
-	URL string `json:"url"`
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
 }
 
 // Torrent represents torrent metadata
 type Torrent struct {
-	Link          string    `json:"link"`
+	Link          string    `json:"link"` // .torrent file URL or magnet URI
 	ContentLength int64     `json:"contentLength"`
 	PubDate       time.Time `json:"pubDate"`
+	InfoHash      string    `json:"infoHash,omitempty"`
+	MagnetURI     string    `json:"magnetURI,omitempty"`
+	FileName      string    `json:"fileName,omitempty"`
+}
+
+// ItunesOwner represents the podcast owner contact
+type ItunesOwner struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+}
+
+// Transcript represents a Podcasting 2.0 episode transcript
+type Transcript struct {
+	URL      string `json:"url"`
+	Type     string `json:"type"`
+	Language string `json:"language,omitempty"`
+	Rel      string `json:"rel,omitempty"`
+}
+
+// Chapters represents Podcasting 2.0 episode chapters
+type Chapters struct {
+	URL  string `json:"url"`
+	Type string `json:"type"`
+}
+
+// Event represents the schedule of an item that describes an event
+type Event struct {
+	End      time.Time `json:"end,omitempty"`
+	Duration int       `json:"duration,omitempty"` // Seconds, used when End is not set
+	Location string    `json:"location,omitempty"`
+	AllDay   bool      `json:"allDay,omitempty"`
 }
@@ -0,0 +1,160 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// ExportItem is the normalized, flat representation of an item used by the
+// tabular export formats
+type ExportItem struct {
+	Title           string   `json:"title"`
+	Link            string   `json:"link"`
+	GUID            string   `json:"guid"`
+	Author          string   `json:"author"`
+	PubDate         string   `json:"pub_date"`
+	Updated         string   `json:"updated"`
+	Categories      []string `json:"categories"`
+	Summary         string   `json:"summary"`
+	ContentText     string   `json:"content_text"`
+	EnclosureURL    string   `json:"enclosure_url"`
+	EnclosureType   string   `json:"enclosure_type"`
+	EnclosureLength int64    `json:"enclosure_length"`
+}
+
+// exportColumns lists the CSV header in ExportItem field order
+var exportColumns = []string{
+	"title", "link", "guid", "author", "pub_date", "updated", "categories",
+	"summary", "content_text", "enclosure_url", "enclosure_type", "enclosure_length",
+}
+
+func init() {
+	RegisterFormat(Format{
+		Name:        "csv",
+		ContentType: "text/csv; charset=utf-8",
+		Extension:   "csv",
+		Generate:    GenerateCSV,
+	})
+	RegisterFormat(Format{
+		Name:        "ndjson",
+		ContentType: "application/x-ndjson; charset=utf-8",
+		Extension:   "ndjson",
+		MediaTypes:  []string{"application/jsonl"},
+		Generate:    GenerateNDJSON,
+	})
+}
+
+// NewExportItem normalizes an item for export. Dates are RFC 3339 with the
+// offset of their own zone, as localized by tz=, and the summary and content
+// are plain text.
+func NewExportItem(item *Item) ExportItem {
+	export := ExportItem{
+		Title:       item.Title,
+		Link:        item.Link,
+		GUID:        item.GUID,
+		Author:      authorNames(item.AllAuthors()),
+		Categories:  item.Category,
+		Summary:     item.SummaryText(),
+		ContentText: item.Text(),
+	}
+
+	// Export the first attachment as the enclosure
+	if attachments := item.AllAttachments(); len(attachments) > 0 {
+		export.EnclosureURL = attachments[0].URL
+		export.EnclosureType = attachments[0].Type
+		export.EnclosureLength = attachments[0].Length
+	}
+
+	// Set GUID from link if not provided
+	if export.GUID == "" {
+		export.GUID = item.Link
+	}
+	if export.Categories == nil {
+		export.Categories = []string{}
+	}
+
+	// Set dates
+	if !item.PubDate.IsZero() {
+		export.PubDate = formatRFC3339(item.PubDate)
+	}
+	if !item.Updated.IsZero() {
+		export.Updated = formatRFC3339(item.Updated)
+	}
+
+	return export
+}
+
+// csvCell escapes a value that spreadsheets would run as a formula with a
+// leading apostrophe, since item fields come from upstream
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// GenerateCSV converts the items of Data to CSV with a header row. Multiple
+// categories are separated by semicolons, and values that start like a
+// formula are escaped.
+func GenerateCSV(data *Data, currentURL string) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(exportColumns); err != nil {
+		return "", err
+	}
+
+	for i := range data.Item {
+		export := NewExportItem(&data.Item[i])
+		record := []string{
+			export.Title,
+			export.Link,
+			export.GUID,
+			export.Author,
+			export.PubDate,
+			export.Updated,
+			strings.Join(export.Categories, ";"),
+			export.Summary,
+			export.ContentText,
+			export.EnclosureURL,
+			export.EnclosureType,
+			"",
+		}
+		if export.EnclosureLength > 0 {
+			record[len(record)-1] = strconv.FormatInt(export.EnclosureLength, 10)
+		}
+		for j := range record {
+			record[j] = csvCell(record[j])
+		}
+
+		if err := writer.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// GenerateNDJSON converts the items of Data to newline-delimited JSON, one
+// object per item
+func GenerateNDJSON(data *Data, currentURL string) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	for i := range data.Item {
+		if err := encoder.Encode(NewExportItem(&data.Item[i])); err != nil {
+			return "", err
+		}
+	}
+
+	return buf.String(), nil
+}
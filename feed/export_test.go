@@ -0,0 +1,131 @@
+package feed
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportTestData() *Data {
+	return &Data{
+		Title: "Test Feed",
+		Item: []Item{
+			{
+				Title:           "Item, \"quoted\"",
+				Link:            "https://example.com/item1",
+				Description:     "<p>First &amp; second</p><p>line</p>",
+				Author:          "John Doe",
+				Category:        []string{"tech", "go"},
+				PubDate:         time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+				EnclosureURL:    "https://example.com/a.mp3",
+				EnclosureType:   "audio/mpeg",
+				EnclosureLength: 1234,
+			},
+			{
+				Title: "Item 2",
+				GUID:  "guid-2",
+			},
+		},
+	}
+}
+
+func TestGenerateCSV(t *testing.T) {
+	output, err := GenerateCSV(exportTestData(), "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateCSV failed: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %d", len(records))
+	}
+	if strings.Join(records[0], ",") != strings.Join(exportColumns, ",") {
+		t.Errorf("Wrong header: %v", records[0])
+	}
+
+	row := records[1]
+	if row[0] != "Item, \"quoted\"" || row[2] != "https://example.com/item1" {
+		t.Errorf("Wrong title or GUID: %v", row)
+	}
+	if row[4] != "2024-01-01T12:00:00Z" || row[5] != "" {
+		t.Errorf("Wrong dates: %q / %q", row[4], row[5])
+	}
+	if row[6] != "tech;go" {
+		t.Errorf("Wrong categories: %s", row[6])
+	}
+	if row[7] != "First & second line" {
+		t.Errorf("Expected derived plain text summary, got %q", row[7])
+	}
+	if row[8] != "First & second\n\nline" {
+		t.Errorf("Expected plain text content, got %q", row[8])
+	}
+	if row[11] != "1234" || records[2][11] != "" {
+		t.Errorf("Wrong enclosure lengths: %q / %q", row[11], records[2][11])
+	}
+}
+
+func TestGenerateCSV_Formulas(t *testing.T) {
+	data := &Data{
+		Title: "Test Feed",
+		Item: []Item{
+			{Title: "=HYPERLINK(\"https://evil.example\")", Link: "https://example.com/1", Author: "@admin", Description: "-1+2"},
+			{Title: "+1 for this", Link: "https://example.com/2", Description: "Plain text"},
+		},
+	}
+
+	output, err := GenerateCSV(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateCSV failed: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{records[1][0], "'=HYPERLINK(\"https://evil.example\")"},
+		{records[1][3], "'@admin"},
+		{records[1][7], "'-1+2"},
+		{records[2][0], "'+1 for this"},
+		{records[2][7], "Plain text"},
+		{records[2][1], "https://example.com/2"},
+	}
+	for _, tt := range tests {
+		if tt.value != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, tt.value)
+		}
+	}
+}
+
+func TestGenerateNDJSON(t *testing.T) {
+	output, err := GenerateNDJSON(exportTestData(), "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateNDJSON failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+
+	var item ExportItem
+	if err := json.Unmarshal([]byte(lines[0]), &item); err != nil {
+		t.Fatalf("Invalid JSON line: %v", err)
+	}
+	if item.Title != "Item, \"quoted\"" || item.Summary != "First & second line" || len(item.Categories) != 2 {
+		t.Errorf("Wrong item: %+v", item)
+	}
+
+	// Empty fields are kept so that every line has the same keys
+	if !strings.Contains(lines[1], `"categories":[]`) || !strings.Contains(lines[1], `"pub_date":""`) {
+		t.Errorf("Expected all keys in every line: %s", lines[1])
+	}
+}
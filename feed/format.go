@@ -0,0 +1,113 @@
+package feed
+
+import (
+	"mime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultFormat is the format used when the request does not ask for one
+const DefaultFormat = "rss"
+
+// Format describes an output format that Data can be rendered to
+type Format struct {
+	// Name is the value of the format query parameter
+	Name string
+
+	// ContentType is sent with the rendered feed
+	ContentType string
+
+	// Extension is the path suffix (without dot) that selects the format, if any
+	Extension string
+
+	// MediaTypes lists additional media types matched against the Accept header
+	MediaTypes []string
+
+	// Explicit formats are only selected by the format parameter or path
+	// suffix, never by the Accept header
+	Explicit bool
+
+	// Stylesheet reports whether the generator emits the xml-stylesheet
+	// processing instruction set in Data.Stylesheet
+	Stylesheet bool
+
+	// Headers are sent with the rendered feed, such as a
+	// Content-Security-Policy
+	Headers map[string]string
+
+	// Generate renders Data for the feed at currentURL
+	Generate func(data *Data, currentURL string) (string, error)
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]*Format)
+)
+
+// RegisterFormat registers an output format, replacing any format with the same name
+func RegisterFormat(format Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[format.Name] = &format
+}
+
+// LookupFormat returns the format registered under name
+func LookupFormat(name string) (*Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	format, ok := formats[name]
+	return format, ok
+}
+
+// LookupFormatByExtension returns the format selected by a path suffix such as "atom"
+func LookupFormatByExtension(ext string) (*Format, bool) {
+	if ext == "" {
+		return nil, false
+	}
+
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	for _, format := range formats {
+		if format.Extension == ext {
+			return format, true
+		}
+	}
+	return nil, false
+}
+
+// LookupFormatByMediaType returns the format producing the given media type
+func LookupFormatByMediaType(mediaType string) (*Format, bool) {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return nil, false
+	}
+
+	// Check content types in name order so lookups are deterministic
+	for _, format := range Formats() {
+		if contentType, _, err := mime.ParseMediaType(format.ContentType); err == nil && contentType == mediaType {
+			return format, true
+		}
+		for _, alias := range format.MediaTypes {
+			if alias == mediaType {
+				return format, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Formats returns all registered formats sorted by name
+func Formats() []*Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	result := make([]*Format, 0, len(formats))
+	for _, format := range formats {
+		result = append(result, format)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
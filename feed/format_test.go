@@ -0,0 +1,51 @@
+package feed
+
+import "testing"
+
+func TestLookupFormat(t *testing.T) {
+	for _, name := range []string{"rss", "atom", "json"} {
+		format, ok := LookupFormat(name)
+		if !ok {
+			t.Fatalf("Expected built-in format %s to be registered", name)
+		}
+		if format.Generate == nil || format.ContentType == "" {
+			t.Errorf("Format %s is incomplete: %+v", name, format)
+		}
+	}
+
+	if _, ok := LookupFormat("unknown"); ok {
+		t.Error("Expected unknown format lookup to fail")
+	}
+}
+
+func TestLookupFormatByExtension(t *testing.T) {
+	format, ok := LookupFormatByExtension("atom")
+	if !ok || format.Name != "atom" {
+		t.Errorf("Expected atom format for .atom, got %+v", format)
+	}
+
+	if _, ok := LookupFormatByExtension(""); ok {
+		t.Error("Expected empty extension lookup to fail")
+	}
+}
+
+func TestLookupFormatByMediaType(t *testing.T) {
+	tests := map[string]string{
+		"application/rss+xml":   "rss",
+		"application/atom+xml":  "atom",
+		"application/json":      "json",
+		"application/feed+json": "json",
+		"APPLICATION/ATOM+XML":  "atom",
+	}
+
+	for mediaType, expected := range tests {
+		format, ok := LookupFormatByMediaType(mediaType)
+		if !ok || format.Name != expected {
+			t.Errorf("LookupFormatByMediaType(%q) = %+v, want %s", mediaType, format, expected)
+		}
+	}
+
+	if _, ok := LookupFormatByMediaType("text/plain"); ok {
+		t.Error("Expected unregistered media type lookup to fail")
+	}
+}
@@ -0,0 +1,185 @@
+package feed
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/url"
+	"path"
+	"strings"
+)
+
+//go:embed templates/preview.html
+var previewTemplateSource string
+
+var previewTemplate = template.Must(template.New("preview").Parse(previewTemplateSource))
+
+// htmlPreview is the view model of the HTML preview page
+type htmlPreview struct {
+	Title       string
+	Link        string
+	Description string
+	Language    string
+	Image       string
+	FeedURL     string
+	Items       []htmlPreviewItem
+}
+
+// htmlPreviewItem is the view model of a single item
+type htmlPreviewItem struct {
+	Title      string
+	Link       string
+	Author     string
+	Date       *htmlPreviewDate
+	Categories []string
+	Summary    string
+	Content    template.HTML
+	Enclosures []htmlPreviewEnclosure
+}
+
+// htmlPreviewDate holds a machine-readable and a display date
+type htmlPreviewDate struct {
+	Value string
+	Text  string
+}
+
+// htmlPreviewEnclosure describes a downloadable file
+type htmlPreviewEnclosure struct {
+	URL  string
+	Name string
+	Type string
+	Size string
+}
+
+func init() {
+	RegisterFormat(Format{
+		Name:        "html",
+		ContentType: "text/html; charset=utf-8",
+		Extension:   "html",
+		Explicit:    true,
+		Headers: map[string]string{
+			"Content-Security-Policy": PreviewContentSecurityPolicy,
+		},
+		Generate: GenerateHTML,
+	})
+}
+
+// PreviewContentSecurityPolicy is sent with the HTML preview page. It forbids
+// scripts, forms and framing, since item content is included as-is.
+const PreviewContentSecurityPolicy = "default-src 'none'; img-src * data:; media-src *; style-src 'unsafe-inline'; " +
+	"base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+// GenerateHTML renders Data as a human-readable HTML preview page. Item
+// content is included as-is; the page must be served with
+// PreviewContentSecurityPolicy, which its meta tag only partly repeats.
+func GenerateHTML(data *Data, currentURL string) (string, error) {
+	preview := htmlPreview{
+		Title:       data.Title,
+		Link:        data.Link,
+		Description: data.Description,
+		Language:    data.Language,
+		Image:       data.Image,
+		FeedURL:     previewFeedURL(currentURL),
+	}
+
+	// Convert items
+	preview.Items = make([]htmlPreviewItem, len(data.Item))
+	for i, item := range data.Item {
+		previewItem := htmlPreviewItem{
+			Title:      item.Title,
+			Link:       item.Link,
+			Author:     authorNames(item.AllAuthors()),
+			Categories: item.Category,
+			Summary:    item.Summary,
+			Content:    template.HTML(item.HTML()),
+		}
+
+		// Set date
+		if !item.PubDate.IsZero() {
+			previewItem.Date = &htmlPreviewDate{
+				Value: formatRFC3339(item.PubDate),
+				Text:  item.PubDate.Format("Jan 2, 2006 15:04 MST"),
+			}
+		}
+
+		// Collect attachments, media content and torrents
+		attachments := item.AllAttachments()
+		for _, attachment := range attachments {
+			enclosure := newPreviewEnclosure(attachment.URL, attachment.Type, attachment.Length)
+			if attachment.Title != "" {
+				enclosure.Name = attachment.Title
+			}
+			previewItem.Enclosures = append(previewItem.Enclosures, enclosure)
+		}
+		if item.Media != nil && item.Media.Content != nil && item.Media.Content.URL != "" && !hasAttachment(attachments, item.Media.Content.URL) {
+			content := item.Media.Content
+			previewItem.Enclosures = append(previewItem.Enclosures, newPreviewEnclosure(content.URL, content.Type, content.FileSize))
+		}
+		if item.Torrent != nil && item.Torrent.Link != "" && !hasAttachment(attachments, item.Torrent.Link) {
+			previewItem.Enclosures = append(previewItem.Enclosures, newPreviewEnclosure(item.Torrent.Link, torrentMIMEType, item.Torrent.ContentLength))
+		}
+
+		preview.Items[i] = previewItem
+	}
+
+	var output bytes.Buffer
+	if err := previewTemplate.Execute(&output, preview); err != nil {
+		return "", err
+	}
+
+	return output.String(), nil
+}
+
+// newPreviewEnclosure builds an enclosure named after the file in its URL
+func newPreviewEnclosure(enclosureURL, mimeType string, length int64) htmlPreviewEnclosure {
+	enclosure := htmlPreviewEnclosure{
+		URL:  enclosureURL,
+		Name: "Download",
+		Type: mimeType,
+		Size: formatBytes(length),
+	}
+	if u, err := url.Parse(enclosureURL); err == nil {
+		if name := path.Base(u.Path); name != "." && name != "/" {
+			enclosure.Name = name
+		}
+	}
+	return enclosure
+}
+
+// previewFeedURL returns the subscription URL of the previewed feed, which is
+// the current URL in the default format
+func previewFeedURL(currentURL string) string {
+	u, err := url.Parse(currentURL)
+	if err != nil {
+		return currentURL
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, ".html")
+	query := u.Query()
+	query.Del("format")
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// formatBytes formats a size in bytes for display
+func formatBytes(size int64) string {
+	if size <= 0 {
+		return ""
+	}
+
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size)
+	units := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
@@ -0,0 +1,75 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateHTML(t *testing.T) {
+	data := &Data{
+		Title:       "Test <Feed>",
+		Link:        "https://example.com",
+		Description: "Test Description",
+		Item: []Item{
+			{
+				Title:           "Item 1",
+				Link:            "https://example.com/item1",
+				Description:     "<p>Item <b>content</b></p>",
+				Author:          "John Doe",
+				Category:        []string{"tech"},
+				PubDate:         time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+				EnclosureURL:    "https://example.com/episode.mp3",
+				EnclosureType:   "audio/mpeg",
+				EnclosureLength: 5 * 1024 * 1024,
+			},
+		},
+	}
+
+	output, err := GenerateHTML(data, "https://grss.example.com/test/feed.html?format=html&limit=5")
+	if err != nil {
+		t.Fatalf("GenerateHTML failed: %v", err)
+	}
+
+	expected := []string{
+		"<title>Test &lt;Feed&gt;</title>",
+		"Content-Security-Policy",
+		`href="https://grss.example.com/test/feed?limit=5"`,
+		`<a href="https://example.com/item1">Item 1</a>`,
+		`<time datetime="2024-01-01T12:00:00Z">Jan 1, 2024 12:00 UTC</time>`,
+		"John Doe",
+		`<span class="category">tech</span>`,
+		"<p>Item <b>content</b></p>",
+		`<a href="https://example.com/episode.mp3">episode.mp3</a> (audio/mpeg) · 5.0 MB`,
+	}
+	for _, s := range expected {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected output to contain %q", s)
+		}
+	}
+}
+
+func TestGenerateHTML_Empty(t *testing.T) {
+	output, err := GenerateHTML(&Data{Title: "Empty"}, "https://grss.example.com/test")
+	if err != nil {
+		t.Fatalf("GenerateHTML failed: %v", err)
+	}
+	if !strings.Contains(output, "No items in feed") {
+		t.Error("Expected empty feed notice")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:                  "",
+		512:                "512 B",
+		1536:               "1.5 KB",
+		3 * 1024 * 1024:    "3.0 MB",
+		1024 * 1024 * 1024: "1.0 GB",
+	}
+	for size, expected := range tests {
+		if got := formatBytes(size); got != expected {
+			t.Errorf("formatBytes(%d) = %q, want %q", size, got, expected)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// icsLineLimit is the maximum length of a content line in octets
+	icsLineLimit = 75
+
+	icsDateTime = "20060102T150405Z"
+	icsDate     = "20060102"
+)
+
+func init() {
+	RegisterFormat(Format{
+		Name:        "ics",
+		ContentType: "text/calendar; charset=utf-8",
+		Extension:   "ics",
+		Generate:    GenerateICS,
+	})
+}
+
+// GenerateICS converts Data to an iCalendar (RFC 5545) calendar with one
+// VEVENT per item. Items without a PubDate have no start and are skipped.
+func GenerateICS(data *Data, currentURL string) (string, error) {
+	var builder strings.Builder
+
+	writeICSLine(&builder, "BEGIN", "VCALENDAR")
+	writeICSLine(&builder, "VERSION", "2.0")
+	writeICSLine(&builder, "PRODID", "-//GRSS//GRSS//EN")
+	writeICSLine(&builder, "CALSCALE", "GREGORIAN")
+	writeICSLine(&builder, "METHOD", "PUBLISH")
+	writeICSLine(&builder, "X-WR-CALNAME", escapeICSText(data.Title))
+	if data.Description != "" {
+		writeICSLine(&builder, "X-WR-CALDESC", escapeICSText(data.Description))
+	}
+	if data.TTL > 0 {
+		writeICSLine(&builder, "REFRESH-INTERVAL;VALUE=DURATION", fmt.Sprintf("PT%dM", data.TTL))
+	}
+
+	for _, item := range data.Item {
+		if item.PubDate.IsZero() {
+			continue
+		}
+
+		// Set UID from GUID, falling back to the link
+		uid := item.GUID
+		if uid == "" {
+			uid = item.Link
+		}
+		if uid == "" {
+			uid = fmt.Sprintf("%x", sha256.Sum256([]byte(item.Title+item.PubDate.UTC().Format(icsDateTime))))
+		}
+
+		// DTSTAMP is when the event was last modified
+		stamp := item.PubDate
+		if !item.Updated.IsZero() {
+			stamp = item.Updated
+		}
+
+		writeICSLine(&builder, "BEGIN", "VEVENT")
+		writeICSLine(&builder, "UID", escapeICSText(uid))
+		writeICSLine(&builder, "DTSTAMP", stamp.UTC().Format(icsDateTime))
+
+		// Set start and end
+		event := item.Event
+		if event != nil && event.AllDay {
+			// Dates are calendar days in the zone of the source, which
+			// may be a different day in UTC
+			writeICSLine(&builder, "DTSTART;VALUE=DATE", item.PubDate.Format(icsDate))
+			if !event.End.IsZero() {
+				writeICSLine(&builder, "DTEND;VALUE=DATE", allDayEnd(item.PubDate, event.End).Format(icsDate))
+			}
+		} else {
+			writeICSLine(&builder, "DTSTART", item.PubDate.UTC().Format(icsDateTime))
+			if event != nil && !event.End.IsZero() {
+				writeICSLine(&builder, "DTEND", event.End.UTC().Format(icsDateTime))
+			} else if event != nil && event.Duration > 0 {
+				writeICSLine(&builder, "DURATION", fmt.Sprintf("PT%dS", event.Duration))
+			}
+		}
+
+		writeICSLine(&builder, "SUMMARY", escapeICSText(item.Title))
+		if description := item.Text(); description != "" {
+			writeICSLine(&builder, "DESCRIPTION", escapeICSText(description))
+		}
+		if item.Link != "" {
+			writeICSLine(&builder, "URL", item.Link)
+		}
+		if event != nil && event.Location != "" {
+			writeICSLine(&builder, "LOCATION", escapeICSText(event.Location))
+		}
+		if len(item.Category) > 0 {
+			categories := make([]string, len(item.Category))
+			for i, category := range item.Category {
+				categories[i] = escapeICSText(category)
+			}
+			writeICSLine(&builder, "CATEGORIES", strings.Join(categories, ","))
+		}
+		for _, attachment := range item.AllAttachments() {
+			name := "ATTACH"
+			if attachment.Type != "" {
+				name += ";FMTTYPE=" + attachment.Type
+			}
+			writeICSLine(&builder, name, attachment.URL)
+		}
+
+		writeICSLine(&builder, "END", "VEVENT")
+	}
+
+	writeICSLine(&builder, "END", "VCALENDAR")
+
+	return builder.String(), nil
+}
+
+// allDayEnd returns the exclusive end date of an all-day event. Sources that
+// give the last day of the event instead, such as the start day of a
+// one-day event, get the day after it.
+func allDayEnd(start, end time.Time) time.Time {
+	if end.Format(icsDate) > start.Format(icsDate) {
+		return end
+	}
+	if end.Before(start) {
+		end = start
+	}
+	return end.AddDate(0, 0, 1)
+}
+
+// writeICSLine writes a content line, folding it at 75 octets without
+// splitting UTF-8 sequences
+func writeICSLine(builder *strings.Builder, name, value string) {
+	line := name + ":" + value
+
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		builder.WriteString(line[:cut])
+		builder.WriteString("\r\n ")
+		line = line[cut:]
+
+		// Continuation lines start with a space
+		limit = icsLineLimit - 1
+	}
+
+	builder.WriteString(line)
+	builder.WriteString("\r\n")
+}
+
+// icsTextEscaper escapes TEXT property values
+var icsTextEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// escapeICSText escapes a TEXT property value
+func escapeICSText(s string) string {
+	return icsTextEscaper.Replace(s)
+}
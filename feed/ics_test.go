@@ -0,0 +1,138 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateICS(t *testing.T) {
+	start := time.Date(2024, 3, 1, 18, 30, 0, 0, time.UTC)
+	data := &Data{
+		Title: "Meetups",
+		Item: []Item{
+			{
+				Title:       "Go Meetup; Spring, 2024",
+				Link:        "https://example.com/meetup",
+				GUID:        "meetup-1",
+				Description: "<p>Talks</p><p>Pizza</p>",
+				Category:    []string{"go", "community"},
+				PubDate:     start,
+				Event: &Event{
+					End:      start.Add(2 * time.Hour),
+					Location: "Berlin",
+				},
+			},
+			{
+				Title:   "Hack Day",
+				PubDate: start,
+				Event:   &Event{AllDay: true, End: start.AddDate(0, 0, 1)},
+			},
+			{
+				Title:   "Talk",
+				Link:    "https://example.com/talk",
+				PubDate: start,
+				Event:   &Event{Duration: 1800},
+			},
+			{
+				Title: "Undated",
+			},
+		},
+	}
+
+	output, err := GenerateICS(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateICS failed: %v", err)
+	}
+
+	expected := []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"X-WR-CALNAME:Meetups\r\n",
+		"UID:meetup-1\r\n",
+		"DTSTART:20240301T183000Z\r\n",
+		"DTEND:20240301T203000Z\r\n",
+		"SUMMARY:Go Meetup\\; Spring\\, 2024\r\n",
+		"DESCRIPTION:Talks\\n\\nPizza\r\n",
+		"LOCATION:Berlin\r\n",
+		"CATEGORIES:go,community\r\n",
+		"DTSTART;VALUE=DATE:20240301\r\n",
+		"DTEND;VALUE=DATE:20240302\r\n",
+		"DURATION:PT1800S\r\n",
+		"END:VCALENDAR\r\n",
+	}
+	for _, s := range expected {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected output to contain %q", s)
+		}
+	}
+
+	if count := strings.Count(output, "BEGIN:VEVENT"); count != 3 {
+		t.Errorf("Expected 3 events, got %d", count)
+	}
+}
+
+func TestWriteICSLine_Folding(t *testing.T) {
+	var builder strings.Builder
+	writeICSLine(&builder, "SUMMARY", strings.Repeat("ä", 60))
+
+	lines := strings.Split(strings.TrimSuffix(builder.String(), "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("Expected folded lines, got %q", builder.String())
+	}
+	for i, line := range lines {
+		if len(line) > icsLineLimit {
+			t.Errorf("Line %d exceeds %d octets: %d", i, icsLineLimit, len(line))
+		}
+		if i > 0 && !strings.HasPrefix(line, " ") {
+			t.Errorf("Continuation line %d must start with a space", i)
+		}
+		if !strings.HasSuffix(strings.TrimPrefix(line, " "), "ä") && i < len(lines)-1 {
+			t.Errorf("Line %d splits a UTF-8 sequence", i)
+		}
+	}
+}
+
+func TestGenerateICS_AllDayInZone(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, tokyo)
+	data := &Data{
+		Title: "Holidays",
+		Item: []Item{
+			{Title: "Holiday", GUID: "holiday", PubDate: day, Event: &Event{AllDay: true, End: day.AddDate(0, 0, 1)}},
+		},
+	}
+
+	output, err := GenerateICS(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateICS failed: %v", err)
+	}
+	for _, s := range []string{"DTSTART;VALUE=DATE:20240301\r\n", "DTEND;VALUE=DATE:20240302\r\n"} {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected output to contain %q:\n%s", s, output)
+		}
+	}
+}
+
+func TestGenerateICS_AllDayInclusiveEnd(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	data := &Data{
+		Title: "Holidays",
+		Item: []Item{
+			{Title: "One day", GUID: "one", PubDate: day, Event: &Event{AllDay: true, End: day}},
+			{Title: "Three days", GUID: "three", PubDate: day, Event: &Event{AllDay: true, End: day.AddDate(0, 0, 3)}},
+		},
+	}
+
+	output, err := GenerateICS(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateICS failed: %v", err)
+	}
+	for _, s := range []string{"DTEND;VALUE=DATE:20240302\r\n", "DTEND;VALUE=DATE:20240304\r\n"} {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected output to contain %q:\n%s", s, output)
+		}
+	}
+	if strings.Contains(output, "DTEND;VALUE=DATE:20240301") {
+		t.Errorf("Expected DTEND after DTSTART:\n%s", output)
+	}
+}
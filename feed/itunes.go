@@ -0,0 +1,177 @@
+package feed
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// itunesNamespace is the Apple Podcasts namespace URI
+	itunesNamespace = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+	// podcastNamespace is the Podcasting 2.0 namespace URI
+	podcastNamespace = "https://podcastindex.org/namespace/1.0"
+)
+
+// ItunesChannelElements holds the channel-level iTunes elements
+type ItunesChannelElements struct {
+	ItunesAuthor   string              `xml:"itunes:author,omitempty"`
+	ItunesCategory *ItunesCategory     `xml:"itunes:category,omitempty"`
+	ItunesExplicit string              `xml:"itunes:explicit,omitempty"`
+	ItunesImage    *ItunesImage        `xml:"itunes:image,omitempty"`
+	ItunesOwner    *ItunesOwnerElement `xml:"itunes:owner,omitempty"`
+}
+
+// ItunesItemElements holds the item-level iTunes and Podcasting 2.0 elements
+type ItunesItemElements struct {
+	ItunesDuration    string              `xml:"itunes:duration,omitempty"`
+	ItunesEpisode     int                 `xml:"itunes:episode,omitempty"`
+	ItunesSeason      int                 `xml:"itunes:season,omitempty"`
+	PodcastTranscript []PodcastTranscript `xml:"podcast:transcript,omitempty"`
+	PodcastChapters   *PodcastChapters    `xml:"podcast:chapters,omitempty"`
+}
+
+// ItunesCategory represents a possibly nested itunes:category element
+type ItunesCategory struct {
+	Text        string          `xml:"text,attr"`
+	Subcategory *ItunesCategory `xml:"itunes:category,omitempty"`
+}
+
+// ItunesImage represents the itunes:image element
+type ItunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+// ItunesOwnerElement represents the itunes:owner element
+type ItunesOwnerElement struct {
+	Name  string `xml:"itunes:name,omitempty"`
+	Email string `xml:"itunes:email"`
+}
+
+// PodcastTranscript represents the podcast:transcript element
+type PodcastTranscript struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Language string `xml:"language,attr,omitempty"`
+	Rel      string `xml:"rel,attr,omitempty"`
+}
+
+// PodcastChapters represents the podcast:chapters element
+type PodcastChapters struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// isPodcast reports whether the feed carries any iTunes metadata
+func isPodcast(data *Data) bool {
+	if data.ItunesAuthor != "" || data.ItunesCategory != "" || data.ItunesExplicit ||
+		data.ItunesImage != "" || data.ItunesOwner != nil {
+		return true
+	}
+
+	for _, item := range data.Item {
+		if item.ItunesDuration > 0 || item.ItunesEpisode > 0 || item.ItunesSeason > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasPodcastExtensions reports whether any item carries Podcasting 2.0 data
+func hasPodcastExtensions(items []Item) bool {
+	for _, item := range items {
+		if len(item.Transcripts) > 0 || item.Chapters != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// buildItunesChannelElements converts the feed's podcast metadata to iTunes elements
+func buildItunesChannelElements(data *Data) ItunesChannelElements {
+	elements := ItunesChannelElements{
+		ItunesAuthor:   data.ItunesAuthor,
+		ItunesCategory: parseItunesCategory(data.ItunesCategory),
+		ItunesExplicit: fmt.Sprintf("%t", data.ItunesExplicit),
+	}
+
+	// Fall back to the generic feed metadata
+	if elements.ItunesAuthor == "" {
+		elements.ItunesAuthor = authorNames(data.AllAuthors())
+	}
+
+	image := data.ItunesImage
+	if image == "" {
+		image = data.Image
+	}
+	if image != "" {
+		elements.ItunesImage = &ItunesImage{Href: image}
+	}
+
+	if data.ItunesOwner != nil && data.ItunesOwner.Email != "" {
+		elements.ItunesOwner = &ItunesOwnerElement{
+			Name:  data.ItunesOwner.Name,
+			Email: data.ItunesOwner.Email,
+		}
+	}
+
+	return elements
+}
+
+// buildItunesItemElements converts an item's podcast metadata to iTunes and Podcasting 2.0 elements
+func buildItunesItemElements(item *Item) ItunesItemElements {
+	elements := ItunesItemElements{
+		ItunesEpisode: item.ItunesEpisode,
+		ItunesSeason:  item.ItunesSeason,
+	}
+
+	if item.ItunesDuration > 0 {
+		elements.ItunesDuration = formatItunesDuration(item.ItunesDuration)
+	}
+
+	for _, transcript := range item.Transcripts {
+		elements.PodcastTranscript = append(elements.PodcastTranscript, PodcastTranscript{
+			URL:      transcript.URL,
+			Type:     transcript.Type,
+			Language: transcript.Language,
+			Rel:      transcript.Rel,
+		})
+	}
+
+	if item.Chapters != nil {
+		elements.PodcastChapters = &PodcastChapters{
+			URL:  item.Chapters.URL,
+			Type: item.Chapters.Type,
+		}
+	}
+
+	return elements
+}
+
+// parseItunesCategory parses "Parent > Child" into nested itunes:category elements
+func parseItunesCategory(category string) *ItunesCategory {
+	var root, current *ItunesCategory
+
+	for _, part := range strings.Split(category, ">") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		next := &ItunesCategory{Text: part}
+		if root == nil {
+			root = next
+		} else {
+			current.Subcategory = next
+		}
+		current = next
+	}
+
+	return root
+}
+
+// formatItunesDuration formats seconds as HH:MM:SS
+func formatItunesDuration(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+}
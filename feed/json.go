@@ -6,44 +6,58 @@ import (
 
 // JSONFeed represents a JSON Feed 1.1
 type JSONFeed struct {
-	Version     string         `json:"version"`
-	Title       string         `json:"title"`
-	HomePageURL string         `json:"home_page_url,omitempty"`
-	FeedURL     string         `json:"feed_url,omitempty"`
-	Description string         `json:"description,omitempty"`
-	Icon        string         `json:"icon,omitempty"`
-	Favicon     string         `json:"favicon,omitempty"`
-	Language    string         `json:"language,omitempty"`
-	Authors     []JSONAuthor   `json:"authors,omitempty"`
-	Items       []JSONItem     `json:"items"`
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url,omitempty"`
+	FeedURL     string       `json:"feed_url,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Icon        string       `json:"icon,omitempty"`
+	Favicon     string       `json:"favicon,omitempty"`
+	Language    string       `json:"language,omitempty"`
+	Authors     []JSONAuthor `json:"authors,omitempty"`
+	Items       []JSONItem   `json:"items"`
 }
 
 // JSONAuthor represents an author in JSON Feed
 type JSONAuthor struct {
-	Name string `json:"name,omitempty"`
-	URL  string `json:"url,omitempty"`
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
 }
 
 // JSONItem represents a single item in JSON Feed
 type JSONItem struct {
-	ID            string         `json:"id"`
-	URL           string         `json:"url,omitempty"`
-	Title         string         `json:"title,omitempty"`
-	ContentHTML   string         `json:"content_html,omitempty"`
-	ContentText   string         `json:"content_text,omitempty"`
-	Summary       string         `json:"summary,omitempty"`
-	DatePublished string         `json:"date_published,omitempty"`
-	DateModified  string         `json:"date_modified,omitempty"`
-	Authors       []JSONAuthor   `json:"authors,omitempty"`
-	Tags          []string       `json:"tags,omitempty"`
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentHTML   string           `json:"content_html,omitempty"`
+	ContentText   string           `json:"content_text,omitempty"`
+	Summary       string           `json:"summary,omitempty"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished string           `json:"date_published,omitempty"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Authors       []JSONAuthor     `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
 	Attachments   []JSONAttachment `json:"attachments,omitempty"`
 }
 
 // JSONAttachment represents an attachment in JSON Feed
 type JSONAttachment struct {
-	URL      string `json:"url"`
-	MIMEType string `json:"mime_type"`
-	SizeInBytes int64 `json:"size_in_bytes,omitempty"`
+	URL               string `json:"url"`
+	MIMEType          string `json:"mime_type"`
+	Title             string `json:"title,omitempty"`
+	SizeInBytes       int64  `json:"size_in_bytes,omitempty"`
+	DurationInSeconds int    `json:"duration_in_seconds,omitempty"`
+}
+
+func init() {
+	RegisterFormat(Format{
+		Name:        "json",
+		ContentType: "application/json; charset=utf-8",
+		Extension:   "json",
+		MediaTypes:  []string{"application/feed+json"},
+		Generate:    GenerateJSON,
+	})
 }
 
 // GenerateJSON converts Data to JSON Feed 1.1 format
@@ -59,20 +73,29 @@ func GenerateJSON(data *Data, currentURL string) (string, error) {
 	}
 
 	// Set authors
-	if data.Author != "" {
-		feed.Authors = []JSONAuthor{
-			{Name: data.Author},
-		}
-	}
+	feed.Authors = jsonAuthors(data.AllAuthors())
 
 	// Convert items
 	feed.Items = make([]JSONItem, len(data.Item))
 	for i, item := range data.Item {
 		jsonItem := JSONItem{
-			ID:          item.GUID,
-			URL:         item.Link,
-			Title:       item.Title,
-			ContentHTML: item.Description,
+			ID:      item.GUID,
+			URL:     item.Link,
+			Title:   item.Title,
+			Summary: item.Summary,
+		}
+
+		// Set content, deriving the text from the HTML and vice versa
+		if item.ContentHTML != "" || item.Description != "" {
+			jsonItem.ContentHTML = item.HTML()
+		}
+		jsonItem.ContentText = item.ContentText
+		if jsonItem.ContentHTML == "" && jsonItem.ContentText == "" {
+			// JSON Feed requires content_html or content_text
+			jsonItem.ContentText = item.Summary
+		}
+		if jsonItem.Summary == "" && jsonItem.ContentHTML != "" {
+			jsonItem.Summary = item.SummaryText()
 		}
 
 		// Set ID from link if not provided
@@ -89,11 +112,7 @@ func GenerateJSON(data *Data, currentURL string) (string, error) {
 		}
 
 		// Set authors
-		if item.Author != "" {
-			jsonItem.Authors = []JSONAuthor{
-				{Name: item.Author},
-			}
-		}
+		jsonItem.Authors = jsonAuthors(item.AllAuthors())
 
 		// Set tags
 		if len(item.Category) > 0 {
@@ -101,13 +120,37 @@ func GenerateJSON(data *Data, currentURL string) (string, error) {
 		}
 
 		// Set attachments
-		if item.EnclosureURL != "" {
-			jsonItem.Attachments = []JSONAttachment{
-				{
-					URL:         item.EnclosureURL,
-					MIMEType:    item.EnclosureType,
-					SizeInBytes: item.EnclosureLength,
-				},
+		attachments := item.AllAttachments()
+		for _, attachment := range attachments {
+			jsonItem.Attachments = append(jsonItem.Attachments, JSONAttachment{
+				URL:               attachment.URL,
+				MIMEType:          attachment.Type,
+				Title:             attachment.Title,
+				SizeInBytes:       attachment.Length,
+				DurationInSeconds: attachment.Duration,
+			})
+		}
+
+		// Expose torrent as an attachment
+		if item.Torrent != nil && item.Torrent.Link != "" && !hasAttachment(attachments, item.Torrent.Link) {
+			jsonItem.Attachments = append(jsonItem.Attachments, JSONAttachment{
+				URL:         item.Torrent.Link,
+				MIMEType:    torrentMIMEType,
+				SizeInBytes: item.Torrent.ContentLength,
+			})
+		}
+
+		// Map Media RSS to image and attachments
+		if item.Media != nil {
+			jsonItem.Image = mediaImage(item.Media)
+			if content := item.Media.Content; content != nil && content.URL != "" && !hasAttachment(attachments, content.URL) {
+				jsonItem.Attachments = append(jsonItem.Attachments, JSONAttachment{
+					URL:               content.URL,
+					MIMEType:          mediaMIMEType(content),
+					Title:             item.Media.Title,
+					SizeInBytes:       content.FileSize,
+					DurationInSeconds: content.Duration,
+				})
 			}
 		}
 
@@ -122,3 +165,19 @@ func GenerateJSON(data *Data, currentURL string) (string, error) {
 
 	return string(output), nil
 }
+
+// jsonAuthors converts people to JSON Feed authors, where an email address
+// serves as the URL when none is set
+func jsonAuthors(people []Person) []JSONAuthor {
+	var authors []JSONAuthor
+	for _, person := range people {
+		author := JSONAuthor{Name: person.Name, URL: person.URL, Avatar: person.Avatar}
+		if author.URL == "" && person.Email != "" {
+			author.URL = "mailto:" + person.Email
+		}
+		if author != (JSONAuthor{}) {
+			authors = append(authors, author)
+		}
+	}
+	return authors
+}
@@ -185,3 +185,151 @@ func TestGenerateJSON_EmptyItems(t *testing.T) {
 		t.Errorf("Expected 0 items, got %d", len(jsonFeed.Items))
 	}
 }
+
+func TestGenerateJSON_WithMedia(t *testing.T) {
+	data := &Data{
+		Title: "Feed with Media",
+		Link:  "https://example.com",
+		Item: []Item{
+			{
+				Title: "Video",
+				Link:  "https://example.com/video",
+				Media: &Media{
+					Content: &MediaContent{
+						URL:      "https://example.com/video.mp4",
+						Type:     "video/mp4",
+						Duration: 125,
+					},
+					Thumbnail: &MediaThumbnail{URL: "https://example.com/thumb.jpg"},
+					Title:     "Video title",
+				},
+			},
+		},
+	}
+
+	output, err := GenerateJSON(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateJSON failed: %v", err)
+	}
+
+	var jsonFeed JSONFeed
+	err = json.Unmarshal([]byte(output), &jsonFeed)
+	if err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	item := jsonFeed.Items[0]
+	if item.Image != "https://example.com/thumb.jpg" {
+		t.Errorf("Wrong image: %s", item.Image)
+	}
+	if len(item.Attachments) != 1 {
+		t.Fatalf("Expected 1 attachment, got %d", len(item.Attachments))
+	}
+
+	attachment := item.Attachments[0]
+	if attachment.URL != "https://example.com/video.mp4" {
+		t.Errorf("Wrong attachment URL: %s", attachment.URL)
+	}
+	if attachment.MIMEType != "video/mp4" {
+		t.Errorf("Wrong MIME type: %s", attachment.MIMEType)
+	}
+	if attachment.Title != "Video title" {
+		t.Errorf("Wrong attachment title: %s", attachment.Title)
+	}
+	if attachment.DurationInSeconds != 125 {
+		t.Errorf("Wrong duration: %d", attachment.DurationInSeconds)
+	}
+}
+
+func TestMediaMIMEType(t *testing.T) {
+	tests := []struct {
+		content  MediaContent
+		expected string
+	}{
+		{MediaContent{URL: "https://example.com/a.mp3", Type: "audio/mpeg"}, "audio/mpeg"},
+		{MediaContent{URL: "https://example.com/a.png?size=large"}, "image/png"},
+		{MediaContent{URL: "https://example.com/watch"}, "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		if got := mediaMIMEType(&tt.content); got != tt.expected {
+			t.Errorf("mediaMIMEType(%s) = %s, want %s", tt.content.URL, got, tt.expected)
+		}
+	}
+}
+
+func TestGenerateJSON_SummaryAndContent(t *testing.T) {
+	data := &Data{
+		Title: "Test Feed",
+		Item: []Item{
+			{Title: "Both", GUID: "1", ContentHTML: "<p>Full <b>content</b></p>", ContentText: "Full content"},
+			{Title: "Summary only", GUID: "2", Summary: "Just a summary"},
+		},
+	}
+
+	output, err := GenerateJSON(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateJSON failed: %v", err)
+	}
+
+	var jsonFeed JSONFeed
+	if err := json.Unmarshal([]byte(output), &jsonFeed); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	item := jsonFeed.Items[0]
+	if item.ContentHTML != "<p>Full <b>content</b></p>" || item.ContentText != "Full content" || item.Summary != "Full content" {
+		t.Errorf("Wrong content mapping: %+v", item)
+	}
+
+	// JSON Feed requires content, so the summary is used as text
+	item = jsonFeed.Items[1]
+	if item.ContentHTML != "" || item.ContentText != "Just a summary" || item.Summary != "Just a summary" {
+		t.Errorf("Wrong summary-only mapping: %+v", item)
+	}
+}
+
+func TestGenerateJSON_AuthorsAndAttachments(t *testing.T) {
+	data := &Data{
+		Title: "Test Feed",
+		Link:  "https://example.com",
+		Item: []Item{
+			{
+				Title: "Item",
+				Authors: []Person{
+					{Name: "Jane Doe", URL: "https://example.com/jane", Avatar: "https://example.com/jane.png"},
+					{Name: "John Doe", Email: "john@example.com"},
+				},
+				EnclosureURL: "https://example.com/a.mp3",
+				Attachments: []Attachment{
+					{URL: "https://example.com/a.mp3", Type: "audio/mpeg"},
+					{URL: "https://example.com/b.mp4", Type: "video/mp4", Title: "Video", Duration: 90},
+				},
+			},
+		},
+	}
+
+	output, err := GenerateJSON(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateJSON failed: %v", err)
+	}
+
+	var jsonFeed JSONFeed
+	if err := json.Unmarshal([]byte(output), &jsonFeed); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	item := jsonFeed.Items[0]
+	if len(item.Authors) != 2 || item.Authors[0].Avatar != "https://example.com/jane.png" {
+		t.Fatalf("Wrong authors: %+v", item.Authors)
+	}
+	if item.Authors[1].URL != "mailto:john@example.com" {
+		t.Errorf("Expected email as mailto URL, got %s", item.Authors[1].URL)
+	}
+	if len(item.Attachments) != 2 {
+		t.Fatalf("Expected enclosure and attachments without duplicates, got %+v", item.Attachments)
+	}
+	if attachment := item.Attachments[1]; attachment.Title != "Video" || attachment.DurationInSeconds != 90 {
+		t.Errorf("Wrong attachment: %+v", attachment)
+	}
+}
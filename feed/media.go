@@ -0,0 +1,139 @@
+package feed
+
+import (
+	"mime"
+	"path"
+	"strings"
+)
+
+// mediaNamespace is the Media RSS namespace URI
+const mediaNamespace = "http://search.yahoo.com/mrss/"
+
+// MediaElements holds the Media RSS elements shared by RSS items and Atom entries
+type MediaElements struct {
+	MediaContent     *MediaContentElement   `xml:"media:content,omitempty"`
+	MediaPlayer      *MediaPlayerElement    `xml:"media:player,omitempty"`
+	MediaThumbnail   *MediaThumbnailElement `xml:"media:thumbnail,omitempty"`
+	MediaTitle       *MediaText             `xml:"media:title,omitempty"`
+	MediaDescription *MediaText             `xml:"media:description,omitempty"`
+	MediaCredit      *MediaText             `xml:"media:credit,omitempty"`
+}
+
+// MediaContentElement represents the media:content element
+type MediaContentElement struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr,omitempty"`
+	Medium   string `xml:"medium,attr,omitempty"`
+	FileSize int64  `xml:"fileSize,attr,omitempty"`
+	Width    int    `xml:"width,attr,omitempty"`
+	Height   int    `xml:"height,attr,omitempty"`
+	Duration int    `xml:"duration,attr,omitempty"`
+}
+
+// MediaPlayerElement represents the media:player element
+type MediaPlayerElement struct {
+	URL    string `xml:"url,attr"`
+	Width  int    `xml:"width,attr,omitempty"`
+	Height int    `xml:"height,attr,omitempty"`
+}
+
+// MediaThumbnailElement represents the media:thumbnail element
+type MediaThumbnailElement struct {
+	URL    string `xml:"url,attr"`
+	Width  int    `xml:"width,attr,omitempty"`
+	Height int    `xml:"height,attr,omitempty"`
+}
+
+// MediaText represents media:title, media:description and media:credit
+type MediaText struct {
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+// buildMediaElements converts Media to its Media RSS elements
+func buildMediaElements(media *Media) MediaElements {
+	var elements MediaElements
+	if media == nil {
+		return elements
+	}
+
+	if media.Content != nil && media.Content.URL != "" {
+		elements.MediaContent = &MediaContentElement{
+			URL:      media.Content.URL,
+			Type:     media.Content.Type,
+			Medium:   media.Content.Medium,
+			FileSize: media.Content.FileSize,
+			Width:    media.Content.Width,
+			Height:   media.Content.Height,
+			Duration: media.Content.Duration,
+		}
+	}
+
+	if media.Player != nil && media.Player.URL != "" {
+		elements.MediaPlayer = &MediaPlayerElement{
+			URL:    media.Player.URL,
+			Width:  media.Player.Width,
+			Height: media.Player.Height,
+		}
+	}
+
+	if media.Thumbnail != nil && media.Thumbnail.URL != "" {
+		elements.MediaThumbnail = &MediaThumbnailElement{
+			URL:    media.Thumbnail.URL,
+			Width:  media.Thumbnail.Width,
+			Height: media.Thumbnail.Height,
+		}
+	}
+
+	if media.Title != "" {
+		elements.MediaTitle = &MediaText{Type: "plain", Value: media.Title}
+	}
+	if media.Description != "" {
+		elements.MediaDescription = &MediaText{Type: "plain", Value: media.Description}
+	}
+	if media.Credit != "" {
+		elements.MediaCredit = &MediaText{Value: media.Credit}
+	}
+
+	return elements
+}
+
+// hasMedia reports whether any item carries Media RSS data
+func hasMedia(items []Item) bool {
+	for _, item := range items {
+		if item.Media != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaImage returns the image URL that best represents the item's media
+func mediaImage(media *Media) string {
+	if media == nil {
+		return ""
+	}
+	if media.Thumbnail != nil && media.Thumbnail.URL != "" {
+		return media.Thumbnail.URL
+	}
+	if media.Content != nil && media.Content.Medium == "image" {
+		return media.Content.URL
+	}
+	return ""
+}
+
+// mediaMIMEType returns the MIME type of media content, guessing from the
+// file extension when the route did not provide one
+func mediaMIMEType(content *MediaContent) string {
+	if content.Type != "" {
+		return content.Type
+	}
+
+	if ext := path.Ext(strings.SplitN(content.URL, "?", 2)[0]); ext != "" {
+		if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+			return mimeType
+		}
+	}
+
+	return "application/octet-stream"
+}
@@ -0,0 +1,387 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Namespace URIs recognized by the parser in addition to the ones used for output
+const (
+	atomNamespace    = "http://www.w3.org/2005/Atom"
+	contentNamespace = "http://purl.org/rss/1.0/modules/content/"
+	dcNamespace      = "http://purl.org/dc/elements/1.1/"
+	rdfNamespace     = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+)
+
+// ErrUnknownFormat is returned when the input is not a recognized feed format
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+// Parse reads an RSS 0.9x/1.0/2.0, Atom 1.0 or JSON Feed 1.0/1.1 document into Data
+func Parse(body []byte) (*Data, error) {
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, ErrUnknownFormat
+	}
+
+	if trimmed[0] == '{' {
+		return parseJSONFeed(trimmed)
+	}
+
+	root, err := parseXMLTree(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	}
+
+	switch strings.ToLower(root.Name.Local) {
+	case "rss", "rdf":
+		return parseRSS(root), nil
+	case "feed":
+		return parseAtom(root), nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// xmlNode is a namespace-aware element tree node. Text nodes have an empty name.
+type xmlNode struct {
+	Name     xml.Name
+	Attr     []xml.Attr
+	Text     string
+	Children []*xmlNode
+}
+
+// parseXMLTree decodes an XML document leniently into an element tree
+func parseXMLTree(body []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(sanitizeXML(body)))
+	decoder.Strict = false
+	decoder.AutoClose = htmlVoidElements
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if isUTF8Label(label) {
+			return input, nil
+		}
+		return charset.NewReaderLabel(label, input)
+	}
+
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Keep whatever was parsed from truncated documents
+			if len(root.Children) > 0 {
+				break
+			}
+			return nil, err
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{Name: t.Name, Attr: t.Attr}
+			parent.Children = append(parent.Children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.Children = append(parent.Children, &xmlNode{Text: string(t)})
+		}
+	}
+
+	for _, child := range root.Children {
+		if child.Name.Local != "" {
+			return child, nil
+		}
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// htmlVoidElements lists the HTML void elements that may appear unescaped in
+// feed content. Unlike xml.HTMLAutoClose it excludes "link", which is a
+// regular element in RSS.
+var htmlVoidElements = []string{
+	"area", "base", "br", "col", "embed", "hr", "img", "input",
+	"meta", "param", "source", "track", "wbr",
+}
+
+// invalidXMLChars matches control characters that are not allowed in XML 1.0
+var invalidXMLChars = regexp.MustCompile("[\x00-\x08\x0B\x0C\x0E-\x1F]")
+
+// encodingDecl matches the encoding in an XML declaration
+var encodingDecl = regexp.MustCompile(`^<\?xml[^>]*encoding=["']([^"']+)["']`)
+
+// sanitizeXML removes characters that would abort decoding and repairs invalid
+// UTF-8 in documents that claim to be UTF-8
+func sanitizeXML(body []byte) []byte {
+	body = invalidXMLChars.ReplaceAll(body, nil)
+
+	label := "utf-8"
+	if match := encodingDecl.FindSubmatch(body); match != nil {
+		label = string(match[1])
+	}
+	if isUTF8Label(label) && !utf8.Valid(body) {
+		body = bytes.ToValidUTF8(body, []byte("�"))
+	}
+
+	return body
+}
+
+// isUTF8Label reports whether an encoding label names UTF-8
+func isUTF8Label(label string) bool {
+	label = strings.ToLower(strings.TrimSpace(label))
+	return label == "utf-8" || label == "utf8"
+}
+
+// child returns the first child element with the given local name in one of the namespaces.
+// An empty namespace list matches elements without a namespace (or with an unknown one).
+func (n *xmlNode) child(local string, namespaces ...string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.Children {
+		if c.matches(local, namespaces...) {
+			return c
+		}
+	}
+	return nil
+}
+
+// children returns all child elements with the given local name in one of the namespaces
+func (n *xmlNode) children(local string, namespaces ...string) []*xmlNode {
+	if n == nil {
+		return nil
+	}
+	var result []*xmlNode
+	for _, c := range n.Children {
+		if c.matches(local, namespaces...) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// matches reports whether the node has the given local name and namespace
+func (n *xmlNode) matches(local string, namespaces ...string) bool {
+	if n.Name.Local == "" || !strings.EqualFold(n.Name.Local, local) {
+		return false
+	}
+
+	space := canonicalNamespace(n.Name.Space)
+	if len(namespaces) == 0 {
+		return !extensionNamespaces[space]
+	}
+	for _, ns := range namespaces {
+		if space == canonicalNamespace(ns) {
+			return true
+		}
+	}
+	return false
+}
+
+// namespacePrefixes maps conventional prefixes to namespace URIs, for documents
+// that use a prefix without declaring it
+var namespacePrefixes = map[string]string{
+	"atom":    atomNamespace,
+	"content": contentNamespace,
+	"dc":      dcNamespace,
+	"media":   mediaNamespace,
+	"itunes":  itunesNamespace,
+	"podcast": podcastNamespace,
+	"torrent": torrentNamespace,
+	"rdf":     rdfNamespace,
+}
+
+// extensionNamespaces holds the namespaces that are never part of the core RSS vocabulary
+var extensionNamespaces = map[string]bool{}
+
+func init() {
+	for _, ns := range namespacePrefixes {
+		extensionNamespaces[canonicalNamespace(ns)] = true
+	}
+}
+
+// canonicalNamespace normalizes a namespace URI or undeclared prefix for comparison
+func canonicalNamespace(space string) string {
+	if uri, ok := namespacePrefixes[space]; ok {
+		space = uri
+	}
+	return strings.TrimSuffix(strings.ToLower(space), "/")
+}
+
+// content returns the node's content as markup when it contains unescaped
+// elements, and as text otherwise
+func (n *xmlNode) content() string {
+	if n == nil {
+		return ""
+	}
+	for _, c := range n.Children {
+		if c.Name.Local != "" {
+			return n.innerXML()
+		}
+	}
+	return n.text()
+}
+
+// text returns the trimmed text content of the node
+func (n *xmlNode) text() string {
+	if n == nil {
+		return ""
+	}
+	var builder strings.Builder
+	for _, c := range n.Children {
+		if c.Name.Local == "" {
+			builder.WriteString(c.Text)
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// childText returns the text of the first matching child element
+func (n *xmlNode) childText(local string, namespaces ...string) string {
+	return n.child(local, namespaces...).text()
+}
+
+// attr returns the value of the attribute with the given local name
+func (n *xmlNode) attr(local string) string {
+	if n == nil {
+		return ""
+	}
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Name.Local, local) {
+			return strings.TrimSpace(a.Value)
+		}
+	}
+	return ""
+}
+
+// innerXML renders the node's children back to markup
+func (n *xmlNode) innerXML() string {
+	var builder strings.Builder
+	for _, c := range n.Children {
+		c.render(&builder)
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// render writes the node and its children as markup, dropping namespace prefixes
+func (n *xmlNode) render(builder *strings.Builder) {
+	if n.Name.Local == "" {
+		xml.EscapeText(builder, []byte(n.Text))
+		return
+	}
+
+	builder.WriteString("<" + n.Name.Local)
+	for _, a := range n.Attr {
+		if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+			continue
+		}
+		builder.WriteString(" " + a.Name.Local + `="`)
+		xml.EscapeText(builder, []byte(a.Value))
+		builder.WriteString(`"`)
+	}
+	builder.WriteString(">")
+	for _, c := range n.Children {
+		c.render(builder)
+	}
+	builder.WriteString("</" + n.Name.Local + ">")
+}
+
+// dateLayouts lists the layouts tried by ParseDate, most common first
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339Nano,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 06 15:04:05 -0700",
+	"Mon, 2 Jan 06 15:04:05 MST",
+	"Mon, 2 January 2006 15:04:05 -0700",
+	"Mon, 2 January 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC850,
+	time.UnixDate,
+	time.RubyDate,
+	time.ANSIC,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"January 2, 2006 15:04:05",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// ParseDate parses a feed date leniently, accepting RFC 822/1123, RFC 3339 and
+// a range of common malformed variants. Dates without a zone are read as UTC.
+func ParseDate(value string) (time.Time, bool) {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	// Normalize zone spellings that time.Parse does not understand
+	value = strings.TrimSuffix(value, " (UTC)")
+	if strings.HasSuffix(value, " UT") {
+		value += "C"
+	}
+	value = strings.Replace(value, " GMT+", " +", 1)
+	value = strings.Replace(value, " GMT-", " -", 1)
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+
+	// Unix timestamps
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil && seconds > 0 {
+		return time.Unix(seconds, 0).UTC(), true
+	}
+
+	return time.Time{}, false
+}
+
+// parseDuration parses an iTunes duration given as seconds, MM:SS or HH:MM:SS
+func parseDuration(value string) int {
+	seconds := 0
+	for _, part := range strings.Split(strings.TrimSpace(value), ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0
+		}
+		seconds = seconds*60 + int(n)
+	}
+	return seconds
+}
@@ -0,0 +1,173 @@
+package feed
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// parseAtom converts an Atom 1.0 element tree into Data
+func parseAtom(root *xmlNode) *Data {
+	data := &Data{
+		Title:    atomText(root.child("title", atomNamespace, "")),
+		Link:     atomLink(root, "alternate"),
+		Subtitle: atomText(root.child("subtitle", atomNamespace, "")),
+		Icon:     atomChildText(root, "icon"),
+		Logo:     atomChildText(root, "logo"),
+		Language: root.attr("lang"),
+	}
+	data.Description = data.Subtitle
+	data.Image = data.Logo
+
+	data.Authors = atomPeople(root.children("author", atomNamespace, ""))
+	if t, ok := ParseDate(atomChildText(root, "updated")); ok {
+		data.PubDate = t
+	}
+
+	entries := root.children("entry", atomNamespace, "")
+	data.Item = make([]Item, 0, len(entries))
+	for _, entry := range entries {
+		data.Item = append(data.Item, parseAtomEntry(entry, data.Link))
+	}
+
+	return data
+}
+
+// parseAtomEntry converts an Atom entry element into an Item
+func parseAtomEntry(entry *xmlNode, baseURL string) Item {
+	item := Item{
+		Title: atomText(entry.child("title", atomNamespace, "")),
+		Link:  resolveURL(baseURL, atomLink(entry, "alternate")),
+		GUID:  atomChildText(entry, "id"),
+	}
+
+	// Set summary and content
+	item.Summary = atomText(entry.child("summary", atomNamespace, ""))
+	if content := entry.child("content", atomNamespace, ""); content != nil {
+		if contentType := content.attr("type"); contentType == "" || contentType == "text" {
+			item.ContentText = content.text()
+		} else {
+			item.ContentHTML = atomContent(content)
+		}
+	}
+
+	// Set dates
+	published := atomChildText(entry, "published")
+	updated := atomChildText(entry, "updated")
+	if t, ok := ParseDate(firstNonEmpty(published, updated)); ok {
+		item.PubDate = t
+	}
+	if t, ok := ParseDate(updated); ok {
+		item.Updated = t
+	}
+
+	// Set authors
+	item.Authors = atomPeople(entry.children("author", atomNamespace, ""))
+
+	// Set categories
+	for _, category := range entry.children("category", atomNamespace, "") {
+		if term := firstNonEmpty(category.attr("label"), category.attr("term")); term != "" {
+			item.Category = append(item.Category, term)
+		}
+	}
+
+	// Set enclosures and typed links
+	item.Attachments, item.Links = parseAtomLinks(entry.children("link", atomNamespace, ""), baseURL)
+
+	parseItemExtensions(entry, &item)
+
+	return item
+}
+
+// atomChildText returns the text of an Atom child element
+func atomChildText(node *xmlNode, local string) string {
+	return node.childText(local, atomNamespace, "")
+}
+
+// atomLink returns the href of the first link with the given rel, where a
+// missing rel counts as "alternate"
+func atomLink(node *xmlNode, rel string) string {
+	for _, link := range node.children("link", atomNamespace, "") {
+		linkRel := link.attr("rel")
+		if linkRel == rel || (linkRel == "" && rel == "alternate") {
+			return link.attr("href")
+		}
+	}
+	return ""
+}
+
+// atomPeople converts Atom person constructs into people
+func atomPeople(nodes []*xmlNode) []Person {
+	var people []Person
+	for _, node := range nodes {
+		person := Person{
+			Name:  atomChildText(node, "name"),
+			Email: atomChildText(node, "email"),
+			URL:   atomChildText(node, "uri"),
+		}
+		if person != (Person{}) {
+			people = append(people, person)
+		}
+	}
+	return people
+}
+
+// parseAtomLinks splits link elements into enclosures and typed links,
+// skipping the alternate and self links
+func parseAtomLinks(nodes []*xmlNode, baseURL string) ([]Attachment, []Link) {
+	var attachments []Attachment
+	var links []Link
+	for _, node := range nodes {
+		href := resolveURL(baseURL, node.attr("href"))
+		if href == "" {
+			continue
+		}
+
+		switch rel := node.attr("rel"); rel {
+		case "", "alternate", "self":
+		case "enclosure":
+			attachment := Attachment{URL: href, Type: node.attr("type"), Title: node.attr("title")}
+			attachment.Length, _ = strconv.ParseInt(node.attr("length"), 10, 64)
+			attachments = append(attachments, attachment)
+		default:
+			links = append(links, Link{Href: href, Rel: rel, Type: node.attr("type"), Title: node.attr("title")})
+		}
+	}
+	return attachments, links
+}
+
+// atomText returns an Atom text construct as plain text
+func atomText(node *xmlNode) string {
+	if node == nil {
+		return ""
+	}
+	switch node.attr("type") {
+	case "html":
+		return plainText(node.text())
+	case "xhtml":
+		return plainText(node.innerXML())
+	default:
+		return node.text()
+	}
+}
+
+// atomContent returns an Atom text construct as HTML
+func atomContent(node *xmlNode) string {
+	if node == nil {
+		return ""
+	}
+
+	contentType := node.attr("type")
+	switch {
+	case contentType == "xhtml":
+		// Unwrap the mandatory xhtml:div container
+		if div := node.child("div", "http://www.w3.org/1999/xhtml"); div != nil {
+			return div.innerXML()
+		}
+		return node.innerXML()
+	case strings.Contains(contentType, "html"):
+		return node.content()
+	default:
+		return html.EscapeString(node.text())
+	}
+}
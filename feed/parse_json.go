@@ -0,0 +1,113 @@
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonFeedInput mirrors JSONFeed with the JSON Feed 1.0 fields that 1.1 replaced
+type jsonFeedInput struct {
+	JSONFeed
+	Author *JSONAuthor     `json:"author"`
+	Items  []jsonItemInput `json:"items"`
+}
+
+// jsonItemInput mirrors JSONItem with the JSON Feed 1.0 fields that 1.1 replaced
+type jsonItemInput struct {
+	JSONItem
+	ID     json.RawMessage `json:"id"`
+	Author *JSONAuthor     `json:"author"`
+}
+
+// parseJSONFeed converts a JSON Feed 1.0/1.1 document into Data
+func parseJSONFeed(body []byte) (*Data, error) {
+	var input jsonFeedInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON Feed: %w", err)
+	}
+	if !strings.HasPrefix(input.Version, "https://jsonfeed.org/version/") {
+		return nil, ErrUnknownFormat
+	}
+
+	data := &Data{
+		Title:       input.Title,
+		Link:        input.HomePageURL,
+		Description: input.Description,
+		Language:    input.Language,
+		Icon:        firstNonEmpty(input.Favicon, input.Icon),
+		Image:       input.Icon,
+		Authors:     jsonPeople(input.Authors, input.Author),
+	}
+
+	data.Item = make([]Item, 0, len(input.Items))
+	for _, in := range input.Items {
+		item := Item{
+			Title:       in.Title,
+			Link:        resolveURL(data.Link, in.URL),
+			GUID:        jsonID(in.ID),
+			Summary:     in.Summary,
+			ContentHTML: in.ContentHTML,
+			ContentText: in.ContentText,
+			Authors:     jsonPeople(in.Authors, in.Author),
+			Category:    in.Tags,
+		}
+
+		// Set dates
+		if t, ok := ParseDate(in.DatePublished); ok {
+			item.PubDate = t
+		}
+		if t, ok := ParseDate(in.DateModified); ok {
+			item.Updated = t
+		}
+
+		// Set attachments
+		for _, attachment := range in.Attachments {
+			item.Attachments = append(item.Attachments, Attachment{
+				URL:      resolveURL(data.Link, attachment.URL),
+				Type:     attachment.MIMEType,
+				Length:   attachment.SizeInBytes,
+				Title:    attachment.Title,
+				Duration: attachment.DurationInSeconds,
+			})
+		}
+
+		// Set image
+		if in.Image != "" {
+			item.Media = &Media{Thumbnail: &MediaThumbnail{URL: in.Image}}
+		}
+
+		data.Item = append(data.Item, item)
+	}
+
+	return data, nil
+}
+
+// jsonPeople converts JSON Feed 1.1 authors into people, falling back to the
+// 1.0 author. Mailto URLs are read as email addresses.
+func jsonPeople(authors []JSONAuthor, author *JSONAuthor) []Person {
+	if len(authors) == 0 && author != nil {
+		authors = []JSONAuthor{*author}
+	}
+
+	var people []Person
+	for _, a := range authors {
+		person := Person{Name: a.Name, URL: a.URL, Avatar: a.Avatar}
+		if email, ok := strings.CutPrefix(a.URL, "mailto:"); ok {
+			person.Email, person.URL = email, ""
+		}
+		if person != (Person{}) {
+			people = append(people, person)
+		}
+	}
+	return people
+}
+
+// jsonID returns a JSON Feed item ID, which some feeds publish as a number
+func jsonID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	return strings.TrimSpace(string(raw))
+}
@@ -0,0 +1,289 @@
+package feed
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// parseRSS converts an RSS 0.9x, 1.0 (RDF) or 2.0 element tree into Data
+func parseRSS(root *xmlNode) *Data {
+	channel := root.child("channel")
+
+	data := &Data{
+		Title:       channel.childText("title"),
+		Link:        channel.childText("link"),
+		Description: channel.child("description").content(),
+		Language:    firstNonEmpty(channel.childText("language"), channel.childText("language", dcNamespace)),
+		Authors:     rssAuthorList(channel, "managingEditor"),
+	}
+
+	if data.Link == "" {
+		data.Link = atomLinkHref(channel, "alternate")
+	}
+	if t, ok := ParseDate(firstNonEmpty(channel.childText("pubDate"), channel.childText("date", dcNamespace))); ok {
+		data.PubDate = t
+	}
+	if t, ok := ParseDate(channel.childText("lastBuildDate")); ok {
+		data.LastBuildDate = t
+	}
+	if ttl, err := strconv.Atoi(channel.childText("ttl")); err == nil {
+		data.TTL = ttl
+	}
+
+	// RSS 1.0 places the image and items next to the channel instead of inside it
+	image := channel.child("image")
+	if image == nil {
+		image = root.child("image")
+	}
+	data.Image = image.childText("url")
+
+	itemNodes := channel.children("item")
+	if len(itemNodes) == 0 {
+		itemNodes = root.children("item")
+	}
+
+	parseItunesChannel(channel, data)
+
+	data.Item = make([]Item, 0, len(itemNodes))
+	for _, node := range itemNodes {
+		data.Item = append(data.Item, parseRSSItem(node, data.Link))
+	}
+
+	return data
+}
+
+// parseRSSItem converts an RSS item element into an Item
+func parseRSSItem(node *xmlNode, baseURL string) Item {
+	item := Item{
+		Title:    firstNonEmpty(node.childText("title"), node.childText("title", dcNamespace)),
+		Link:     node.childText("link"),
+		Authors:  rssAuthorList(node, "author"),
+		Comments: node.childText("comments"),
+	}
+	if len(item.Authors) == 0 {
+		if author := node.childText("author", itunesNamespace); author != "" {
+			item.Authors = []Person{{Name: author}}
+		}
+	}
+
+	// The description holds the summary when the full content is published separately
+	description := node.child("description").content()
+	if encoded := node.child("encoded", contentNamespace).content(); encoded != "" {
+		item.ContentHTML = encoded
+		item.Summary = plainText(description)
+	} else {
+		item.ContentHTML = description
+	}
+
+	// Fall back to Atom links and permalink GUIDs
+	guid := node.child("guid")
+	item.GUID = firstNonEmpty(guid.text(), node.attr("about"))
+	if item.Link == "" {
+		item.Link = atomLinkHref(node, "alternate")
+	}
+	if item.Link == "" && guid != nil && guid.attr("isPermaLink") != "false" && isHTTPURL(guid.text()) {
+		item.Link = guid.text()
+	}
+	item.Link = resolveURL(baseURL, item.Link)
+
+	// Set dates
+	pubDate := firstNonEmpty(node.childText("pubDate"), node.childText("date", dcNamespace),
+		node.childText("published", atomNamespace), node.childText("updated", atomNamespace))
+	if t, ok := ParseDate(pubDate); ok {
+		item.PubDate = t
+	}
+	if t, ok := ParseDate(node.childText("updated", atomNamespace)); ok {
+		item.Updated = t
+	}
+
+	// Set categories
+	for _, category := range append(node.children("category"), node.children("subject", dcNamespace)...) {
+		if text := category.text(); text != "" {
+			item.Category = append(item.Category, text)
+		}
+	}
+
+	// Set enclosures and typed links. RSS allows a single enclosure, but
+	// feeds publishing several are common.
+	for _, enclosure := range node.children("enclosure") {
+		attachment := Attachment{
+			URL:  resolveURL(baseURL, enclosure.attr("url")),
+			Type: enclosure.attr("type"),
+		}
+		attachment.Length, _ = strconv.ParseInt(enclosure.attr("length"), 10, 64)
+		if attachment.URL != "" {
+			item.Attachments = append(item.Attachments, attachment)
+		}
+	}
+	attachments, links := parseAtomLinks(node.children("link", atomNamespace), baseURL)
+	item.Attachments = append(item.Attachments, attachments...)
+	item.Links = links
+
+	parseItemExtensions(node, &item)
+
+	return item
+}
+
+// parseItunesChannel reads channel-level iTunes metadata into Data
+func parseItunesChannel(channel *xmlNode, data *Data) {
+	data.ItunesAuthor = channel.childText("author", itunesNamespace)
+	data.ItunesImage = channel.child("image", itunesNamespace).attr("href")
+
+	switch strings.ToLower(channel.childText("explicit", itunesNamespace)) {
+	case "true", "yes", "explicit":
+		data.ItunesExplicit = true
+	}
+
+	var categories []string
+	for category := channel.child("category", itunesNamespace); category != nil; category = category.child("category", itunesNamespace) {
+		categories = append(categories, category.attr("text"))
+	}
+	data.ItunesCategory = strings.Join(categories, " > ")
+
+	if owner := channel.child("owner", itunesNamespace); owner != nil {
+		data.ItunesOwner = &ItunesOwner{
+			Name:  owner.childText("name", itunesNamespace),
+			Email: owner.childText("email", itunesNamespace),
+		}
+	}
+
+	if len(data.Authors) == 0 && data.ItunesAuthor != "" {
+		data.Authors = []Person{{Name: data.ItunesAuthor}}
+	}
+}
+
+// rssAuthorList reads the email-style author element with the given name
+// followed by any dc:creator names
+func rssAuthorList(node *xmlNode, local string) []Person {
+	var authors []Person
+	if author := parsePerson(node.childText(local)); author != (Person{}) {
+		authors = append(authors, author)
+	}
+	for _, creator := range node.children("creator", dcNamespace) {
+		if name := creator.text(); name != "" && (len(authors) == 0 || authors[0].Name != name) {
+			authors = append(authors, Person{Name: name})
+		}
+	}
+	return authors
+}
+
+// parseItemExtensions reads the Media RSS, iTunes, Podcasting 2.0 and torrent
+// extensions shared by RSS items and Atom entries
+func parseItemExtensions(node *xmlNode, item *Item) {
+	item.Media = parseMedia(node)
+
+	item.ItunesDuration = parseDuration(node.childText("duration", itunesNamespace))
+	item.ItunesEpisode, _ = strconv.Atoi(node.childText("episode", itunesNamespace))
+	item.ItunesSeason, _ = strconv.Atoi(node.childText("season", itunesNamespace))
+
+	for _, transcript := range node.children("transcript", podcastNamespace) {
+		item.Transcripts = append(item.Transcripts, Transcript{
+			URL:      transcript.attr("url"),
+			Type:     transcript.attr("type"),
+			Language: transcript.attr("language"),
+			Rel:      transcript.attr("rel"),
+		})
+	}
+	if chapters := node.child("chapters", podcastNamespace); chapters != nil {
+		item.Chapters = &Chapters{URL: chapters.attr("url"), Type: chapters.attr("type")}
+	}
+
+	if torrent := node.child("torrent", torrentNamespace); torrent != nil {
+		item.Torrent = &Torrent{
+			Link:      torrent.childText("link", torrentNamespace),
+			FileName:  torrent.childText("fileName", torrentNamespace),
+			InfoHash:  torrent.childText("infoHash", torrentNamespace),
+			MagnetURI: torrent.childText("magnetURI", torrentNamespace),
+		}
+		item.Torrent.ContentLength, _ = strconv.ParseInt(torrent.childText("contentLength", torrentNamespace), 10, 64)
+		if t, ok := ParseDate(torrent.childText("pubDate", torrentNamespace)); ok {
+			item.Torrent.PubDate = t
+		}
+		if item.Torrent.Link == "" {
+			item.Torrent.Link = item.Torrent.MagnetURI
+		}
+	}
+}
+
+// parseMedia reads Media RSS elements, looking inside media:group as well
+func parseMedia(node *xmlNode) *Media {
+	media := &Media{}
+	for _, scope := range []*xmlNode{node, node.child("group", mediaNamespace)} {
+		if scope == nil {
+			continue
+		}
+
+		if content := scope.child("content", mediaNamespace); content != nil && media.Content == nil {
+			media.Content = &MediaContent{
+				URL:    content.attr("url"),
+				Type:   content.attr("type"),
+				Medium: content.attr("medium"),
+			}
+			media.Content.FileSize, _ = strconv.ParseInt(content.attr("fileSize"), 10, 64)
+			media.Content.Width, _ = strconv.Atoi(content.attr("width"))
+			media.Content.Height, _ = strconv.Atoi(content.attr("height"))
+			media.Content.Duration, _ = strconv.Atoi(content.attr("duration"))
+
+			// Thumbnails and texts are often nested in media:content
+			scope = content
+		}
+
+		if thumbnail := scope.child("thumbnail", mediaNamespace); thumbnail != nil && media.Thumbnail == nil {
+			media.Thumbnail = &MediaThumbnail{URL: thumbnail.attr("url")}
+			media.Thumbnail.Width, _ = strconv.Atoi(thumbnail.attr("width"))
+			media.Thumbnail.Height, _ = strconv.Atoi(thumbnail.attr("height"))
+		}
+
+		media.Title = firstNonEmpty(media.Title, scope.childText("title", mediaNamespace))
+		media.Description = firstNonEmpty(media.Description, scope.childText("description", mediaNamespace))
+		media.Credit = firstNonEmpty(media.Credit, scope.childText("credit", mediaNamespace))
+	}
+
+	if media.Content == nil && media.Thumbnail == nil && media.Title == "" && media.Description == "" && media.Credit == "" {
+		return nil
+	}
+	return media
+}
+
+// atomLinkHref returns the href of the first atom:link with the given rel
+func atomLinkHref(node *xmlNode, rel string) string {
+	for _, link := range node.children("link", atomNamespace) {
+		linkRel := link.attr("rel")
+		if linkRel == rel || (linkRel == "" && rel == "alternate") {
+			return link.attr("href")
+		}
+	}
+	return ""
+}
+
+// resolveURL resolves a possibly relative reference against a base URL
+func resolveURL(base, ref string) string {
+	if ref == "" || base == "" {
+		return ref
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// isHTTPURL reports whether s is an absolute HTTP(S) URL
+func isHTTPURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
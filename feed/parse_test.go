@@ -0,0 +1,353 @@
+package feed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_RSS2(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <title>Test Feed</title>
+    <link>https://example.com/</link>
+    <atom:link href="https://example.com/feed" rel="self" type="application/rss+xml"/>
+    <description>Test &amp; Description</description>
+    <language>en</language>
+    <item>
+      <title>Item 1</title>
+      <link>/item1</link>
+      <description>Short</description>
+      <content:encoded><![CDATA[<p>Full content</p>]]></content:encoded>
+      <dc:creator>John Doe</dc:creator>
+      <category>tech</category>
+      <category>golang</category>
+      <guid isPermaLink="false">item1</guid>
+      <pubDate>Mon, 01 Jan 2024 12:00:00 GMT</pubDate>
+      <enclosure url="https://example.com/a.mp3" type="audio/mpeg" length="1234"/>
+      <media:thumbnail url="https://example.com/thumb.jpg" width="120"/>
+    </item>
+  </channel>
+</rss>`
+
+	data, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if data.Title != "Test Feed" {
+		t.Errorf("Expected title 'Test Feed', got '%s'", data.Title)
+	}
+	if data.Link != "https://example.com/" {
+		t.Errorf("Expected RSS link rather than atom:link, got '%s'", data.Link)
+	}
+	if data.Description != "Test & Description" {
+		t.Errorf("Wrong description: %s", data.Description)
+	}
+	if len(data.Item) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(data.Item))
+	}
+
+	item := data.Item[0]
+	if item.Link != "https://example.com/item1" {
+		t.Errorf("Expected resolved link, got '%s'", item.Link)
+	}
+	if item.ContentHTML != "<p>Full content</p>" {
+		t.Errorf("Expected content:encoded, got '%s'", item.ContentHTML)
+	}
+	if item.Summary != "Short" {
+		t.Errorf("Expected description as summary, got '%s'", item.Summary)
+	}
+	if len(item.Authors) != 1 || item.Authors[0].Name != "John Doe" {
+		t.Errorf("Expected dc:creator as author, got %+v", item.Authors)
+	}
+	if len(item.Category) != 2 {
+		t.Errorf("Expected 2 categories, got %d", len(item.Category))
+	}
+	if item.GUID != "item1" {
+		t.Errorf("Wrong GUID: %s", item.GUID)
+	}
+	if !item.PubDate.Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong pubDate: %v", item.PubDate)
+	}
+	if len(item.Attachments) != 1 || item.Attachments[0].URL != "https://example.com/a.mp3" || item.Attachments[0].Length != 1234 {
+		t.Errorf("Wrong enclosure: %+v", item.Attachments)
+	}
+	if item.Media == nil || item.Media.Thumbnail == nil || item.Media.Thumbnail.Width != 120 {
+		t.Error("Expected media thumbnail")
+	}
+}
+
+func TestParse_RSS1(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel rdf:about="https://example.com/">
+    <title>RDF Feed</title>
+    <link>https://example.com/</link>
+    <description>RSS 1.0</description>
+  </channel>
+  <item rdf:about="https://example.com/1">
+    <title>First</title>
+    <link>https://example.com/1</link>
+    <dc:date>2024-01-01T12:00:00+02:00</dc:date>
+  </item>
+</rdf:RDF>`
+
+	data, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if data.Title != "RDF Feed" {
+		t.Errorf("Wrong title: %s", data.Title)
+	}
+	if len(data.Item) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(data.Item))
+	}
+	if data.Item[0].GUID != "https://example.com/1" {
+		t.Errorf("Expected rdf:about as GUID, got '%s'", data.Item[0].GUID)
+	}
+	if !data.Item[0].PubDate.Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Wrong date: %v", data.Item[0].PubDate)
+	}
+}
+
+func TestParse_Atom(t *testing.T) {
+	input := `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="html">Atom &lt;b&gt;Feed&lt;/b&gt;</title>
+  <link href="https://example.com/" rel="alternate"/>
+  <link href="https://example.com/atom" rel="self"/>
+  <updated>2024-01-02T00:00:00Z</updated>
+  <author><name>Jane</name></author>
+  <entry>
+    <title>Entry</title>
+    <link href="https://example.com/entry"/>
+    <id>urn:uuid:1</id>
+    <published>2024-01-01T00:00:00Z</published>
+    <updated>2024-01-02T00:00:00Z</updated>
+    <summary>Plain &lt; text</summary>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Hello <b>world</b></p></div></content>
+    <author><name>A</name></author>
+    <author><name>B</name></author>
+    <category term="news"/>
+  </entry>
+</feed>`
+
+	data, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if data.Title != "Atom Feed" {
+		t.Errorf("Expected HTML title as text, got '%s'", data.Title)
+	}
+	if data.Link != "https://example.com/" {
+		t.Errorf("Wrong link: %s", data.Link)
+	}
+	if len(data.Authors) != 1 || data.Authors[0].Name != "Jane" {
+		t.Errorf("Wrong author: %+v", data.Authors)
+	}
+
+	item := data.Item[0]
+	if item.GUID != "urn:uuid:1" {
+		t.Errorf("Wrong GUID: %s", item.GUID)
+	}
+	if item.ContentHTML != "<p>Hello <b>world</b></p>" {
+		t.Errorf("Expected unwrapped XHTML content, got '%s'", item.ContentHTML)
+	}
+	if item.Summary != "Plain < text" {
+		t.Errorf("Wrong summary: %s", item.Summary)
+	}
+	if authorNames(item.AllAuthors()) != "A, B" {
+		t.Errorf("Wrong author: %+v", item.Authors)
+	}
+	if item.PubDate.Day() != 1 || item.Updated.Day() != 2 {
+		t.Errorf("Wrong dates: %v / %v", item.PubDate, item.Updated)
+	}
+	if len(item.Category) != 1 || item.Category[0] != "news" {
+		t.Errorf("Wrong categories: %v", item.Category)
+	}
+}
+
+func TestParse_JSONFeed(t *testing.T) {
+	input := `{
+  "version": "https://jsonfeed.org/version/1",
+  "title": "JSON Feed",
+  "home_page_url": "https://example.com/",
+  "author": {"name": "Legacy Author"},
+  "items": [
+    {"id": 42, "url": "https://example.com/42", "title": "Item", "content_text": "a < b", "date_published": "2024-01-01T00:00:00Z", "tags": ["x"]}
+  ]
+}`
+
+	data, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if data.Title != "JSON Feed" || len(data.Authors) != 1 || data.Authors[0].Name != "Legacy Author" {
+		t.Errorf("Wrong feed metadata: %s / %+v", data.Title, data.Authors)
+	}
+
+	item := data.Item[0]
+	if item.GUID != "42" {
+		t.Errorf("Expected numeric ID as string, got '%s'", item.GUID)
+	}
+	if item.ContentText != "a < b" || item.ContentHTML != "" {
+		t.Errorf("Expected text content, got '%s' / '%s'", item.ContentText, item.ContentHTML)
+	}
+	if item.HTML() != "a &lt; b" {
+		t.Errorf("Expected escaped HTML from text content, got '%s'", item.HTML())
+	}
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	data := &Data{
+		Title: "Round Trip",
+		Link:  "https://example.com",
+		Item: []Item{
+			{Title: "Item", Link: "https://example.com/item", Description: "<p>Body</p>", GUID: "guid-1"},
+		},
+	}
+
+	generators := map[string]func(*Data, string) (string, error){
+		"rss":  GenerateRSS,
+		"atom": GenerateAtom,
+		"json": GenerateJSON,
+	}
+	for name, generate := range generators {
+		output, err := generate(data, "https://example.com/feed")
+		if err != nil {
+			t.Fatalf("%s: generate failed: %v", name, err)
+		}
+
+		parsed, err := Parse([]byte(output))
+		if err != nil {
+			t.Fatalf("%s: Parse failed: %v", name, err)
+		}
+		if parsed.Title != "Round Trip" || len(parsed.Item) != 1 {
+			t.Fatalf("%s: wrong result: %+v", name, parsed)
+		}
+		if parsed.Item[0].GUID != "guid-1" || parsed.Item[0].ContentHTML != "<p>Body</p>" || parsed.Item[0].Summary != "Body" {
+			t.Errorf("%s: wrong item: %+v", name, parsed.Item[0])
+		}
+	}
+}
+
+func TestParse_BrokenInput(t *testing.T) {
+	// Latin-1 document, control characters, invalid UTF-8 and an undeclared prefix
+	latin1 := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><rss><channel><title>Caf\xe9</title></channel></rss>"
+	data, err := Parse([]byte(latin1))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if data.Title != "Café" {
+		t.Errorf("Expected decoded Latin-1 title, got '%s'", data.Title)
+	}
+
+	broken := "<rss><channel><title>Bad\x01 \xff</title><item><title>A &nbsp; B</title><dc:creator>X</dc:creator></item></channel></rss>"
+	data, err = Parse([]byte(broken))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if data.Title != "Bad �" {
+		t.Errorf("Wrong title: %q", data.Title)
+	}
+	if len(data.Item) != 1 || authorNames(data.Item[0].AllAuthors()) != "X" {
+		t.Errorf("Expected undeclared dc prefix to be recognized: %+v", data.Item)
+	}
+
+	if _, err := Parse([]byte("<html><body>Not a feed</body></html>")); err != ErrUnknownFormat {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	expected := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	inputs := []string{
+		"Tue, 02 Jan 2024 15:04:05 +0000",
+		"Tue, 2 Jan 2024 15:04:05 GMT",
+		"Tue, 02 Jan 2024 15:04:05 UT",
+		"2024-01-02T15:04:05Z",
+		"2024-01-02T15:04:05.000Z",
+		"2024-01-02 15:04:05",
+		"  Tue,  02 Jan 2024   15:04:05 +0000 ",
+		"1704207845",
+	}
+
+	for _, input := range inputs {
+		got, ok := ParseDate(input)
+		if !ok {
+			t.Errorf("ParseDate(%q) failed", input)
+			continue
+		}
+		if !got.Equal(expected) {
+			t.Errorf("ParseDate(%q) = %v, want %v", input, got, expected)
+		}
+	}
+
+	if _, ok := ParseDate("not a date"); ok {
+		t.Error("Expected invalid date to fail")
+	}
+}
+
+func TestParse_AuthorsAndLinks(t *testing.T) {
+	atom := `<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Feed</title>
+  <entry>
+    <title>Entry</title>
+    <link href="https://example.com/entry"/>
+    <link rel="enclosure" href="https://example.com/a.mp3" type="audio/mpeg" length="10" title="Part 1"/>
+    <link rel="enclosure" href="https://example.com/b.mp3" type="audio/mpeg"/>
+    <link rel="replies" href="https://example.com/entry/comments" type="text/html"/>
+    <author><name>Jane</name><email>jane@example.com</email><uri>https://example.com/jane</uri></author>
+  </entry>
+</feed>`
+
+	data, err := Parse([]byte(atom))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	item := data.Item[0]
+	if len(item.Authors) != 1 || item.Authors[0] != (Person{Name: "Jane", Email: "jane@example.com", URL: "https://example.com/jane"}) {
+		t.Errorf("Wrong authors: %+v", item.Authors)
+	}
+	if len(item.Attachments) != 2 || item.Attachments[0].Title != "Part 1" || item.Attachments[0].Length != 10 {
+		t.Errorf("Wrong attachments: %+v", item.Attachments)
+	}
+	if len(item.Links) != 1 || item.Links[0].Rel != "replies" {
+		t.Errorf("Wrong links: %+v", item.Links)
+	}
+
+	rss := `<rss version="2.0"><channel><title>Feed</title><managingEditor>editor@example.com (Editor)</managingEditor>
+  <item><title>Item</title><author>jane@example.com (Jane)</author></item>
+</channel></rss>`
+
+	data, err = Parse([]byte(rss))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(data.Authors) != 1 || data.Authors[0].Email != "editor@example.com" {
+		t.Errorf("Wrong feed authors: %+v", data.Authors)
+	}
+	if authors := data.Item[0].Authors; len(authors) != 1 || authors[0] != (Person{Name: "Jane", Email: "jane@example.com"}) {
+		t.Errorf("Wrong item authors: %+v", authors)
+	}
+
+	jsonFeed := `{"version": "https://jsonfeed.org/version/1.1", "title": "Feed", "items": [{"id": "1",
+  "authors": [{"name": "Jane", "url": "mailto:jane@example.com", "avatar": "https://example.com/jane.png"}],
+  "attachments": [{"url": "https://example.com/a.mp3", "mime_type": "audio/mpeg", "title": "Episode", "duration_in_seconds": 60}]}]}`
+
+	data, err = Parse([]byte(jsonFeed))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	item = data.Item[0]
+	if len(item.Authors) != 1 || item.Authors[0] != (Person{Name: "Jane", Email: "jane@example.com", Avatar: "https://example.com/jane.png"}) {
+		t.Errorf("Wrong authors: %+v", item.Authors)
+	}
+	if len(item.Attachments) != 1 || item.Attachments[0].Title != "Episode" || item.Attachments[0].Duration != 60 {
+		t.Errorf("Wrong attachments: %+v", item.Attachments)
+	}
+}
@@ -0,0 +1,156 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// RSS 1.0 namespace URI
+const rss1Namespace = "http://purl.org/rss/1.0/"
+
+// RDF represents an RSS 1.0 (RDF Site Summary) document
+type RDF struct {
+	XMLName   xml.Name   `xml:"rdf:RDF"`
+	RDFNS     string     `xml:"xmlns:rdf,attr"`
+	Xmlns     string     `xml:"xmlns,attr"`
+	DCNS      string     `xml:"xmlns:dc,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	Channel   RDFChannel `xml:"channel"`
+	Image     *RDFImage  `xml:"image,omitempty"`
+	Items     []RDFItem  `xml:"item"`
+}
+
+// RDFChannel represents the RSS 1.0 channel
+type RDFChannel struct {
+	About       string       `xml:"rdf:about,attr"`
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description"`
+	Language    string       `xml:"dc:language,omitempty"`
+	Creator     string       `xml:"dc:creator,omitempty"`
+	Date        string       `xml:"dc:date,omitempty"`
+	Image       *RDFResource `xml:"image,omitempty"`
+	Items       RDFItems     `xml:"items"`
+}
+
+// RDFResource references another resource in the document
+type RDFResource struct {
+	Resource string `xml:"rdf:resource,attr"`
+}
+
+// RDFItems lists the channel items in order
+type RDFItems struct {
+	Seq []RDFResource `xml:"rdf:Seq>rdf:li"`
+}
+
+// RDFImage represents the RSS 1.0 image
+type RDFImage struct {
+	About string `xml:"rdf:about,attr"`
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+	URL   string `xml:"url"`
+}
+
+// RDFItem represents an RSS 1.0 item
+type RDFItem struct {
+	About       string       `xml:"rdf:about,attr"`
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description,omitempty"`
+	Content     *ContentHTML `xml:"content:encoded,omitempty"`
+	Creator     string       `xml:"dc:creator,omitempty"`
+	Date        string       `xml:"dc:date,omitempty"`
+	Subject     []string     `xml:"dc:subject,omitempty"`
+}
+
+func init() {
+	RegisterFormat(Format{
+		Name:        "rdf",
+		ContentType: "application/rdf+xml; charset=utf-8",
+		Extension:   "rdf",
+		Generate:    GenerateRDF,
+	})
+}
+
+// GenerateRDF converts Data to RSS 1.0 (RDF) format with Dublin Core metadata
+func GenerateRDF(data *Data, currentURL string) (string, error) {
+	rdf := RDF{
+		RDFNS:     rdfNamespace,
+		Xmlns:     rss1Namespace,
+		DCNS:      dcNamespace,
+		ContentNS: contentNamespace,
+		Channel: RDFChannel{
+			About:       currentURL,
+			Title:       data.Title,
+			Link:        data.Link,
+			Description: data.Description,
+			Language:    data.Language,
+			Creator:     authorNames(data.AllAuthors()),
+		},
+	}
+
+	// Set date
+	if !data.LastBuildDate.IsZero() {
+		rdf.Channel.Date = formatW3CDTF(data.LastBuildDate)
+	} else if !data.PubDate.IsZero() {
+		rdf.Channel.Date = formatW3CDTF(data.PubDate)
+	}
+
+	// Set image
+	if data.Image != "" {
+		rdf.Channel.Image = &RDFResource{Resource: data.Image}
+		rdf.Image = &RDFImage{
+			About: data.Image,
+			Title: data.Title,
+			Link:  data.Link,
+			URL:   data.Image,
+		}
+	}
+
+	// Convert items
+	rdf.Items = make([]RDFItem, len(data.Item))
+	rdf.Channel.Items.Seq = make([]RDFResource, len(data.Item))
+	for i, item := range data.Item {
+		// The item URI identifies the item in the channel sequence
+		about := item.Link
+		if about == "" {
+			about = item.GUID
+		}
+
+		rdfItem := RDFItem{
+			About:   about,
+			Title:   item.Title,
+			Link:    item.Link,
+			Creator: authorNames(item.AllAuthors()),
+			Subject: item.Category,
+		}
+
+		// RSS 1.0 descriptions are plain text, the markup goes to content:encoded
+		rdfItem.Description = item.SummaryText()
+		if content := item.HTML(); content != "" {
+			rdfItem.Content = &ContentHTML{Value: content}
+		}
+
+		// Set date
+		if !item.PubDate.IsZero() {
+			rdfItem.Date = formatW3CDTF(item.PubDate)
+		}
+
+		rdf.Items[i] = rdfItem
+		rdf.Channel.Items.Seq[i] = RDFResource{Resource: about}
+	}
+
+	// Marshal to XML
+	output, err := xml.MarshalIndent(rdf, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return xml.Header + string(output), nil
+}
+
+// formatW3CDTF formats a time.Time to W3C-DTF format (Dublin Core date format)
+// in its own timezone
+func formatW3CDTF(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,59 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateRDF(t *testing.T) {
+	data := &Data{
+		Title:       "Test Feed",
+		Link:        "https://example.com",
+		Description: "Test Description",
+		Language:    "en",
+		Image:       "https://example.com/logo.png",
+		Item: []Item{
+			{
+				Title:       "Item 1",
+				Link:        "https://example.com/item1",
+				Description: "<p>Description &amp; more</p>",
+				Author:      "John Doe",
+				Category:    []string{"tech"},
+				PubDate:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	output, err := GenerateRDF(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateRDF failed: %v", err)
+	}
+
+	expected := []string{
+		`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"`,
+		`<channel rdf:about="https://example.com/feed">`,
+		`<rdf:li rdf:resource="https://example.com/item1"></rdf:li>`,
+		`<image rdf:resource="https://example.com/logo.png"></image>`,
+		`<item rdf:about="https://example.com/item1">`,
+		"<description>Description &amp; more</description>",
+		"<content:encoded><![CDATA[<p>Description &amp; more</p>]]></content:encoded>",
+		"<dc:creator>John Doe</dc:creator>",
+		"<dc:date>2024-01-01T12:00:00Z</dc:date>",
+		"<dc:subject>tech</dc:subject>",
+	}
+	for _, s := range expected {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected output to contain %q", s)
+		}
+	}
+
+	// The output should be readable by RSS 1.0 consumers
+	parsed, err := Parse([]byte(output))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(parsed.Item) != 1 || !parsed.Item[0].PubDate.Equal(data.Item[0].PubDate) {
+		t.Errorf("Wrong parsed items: %+v", parsed.Item)
+	}
+}
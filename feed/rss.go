@@ -7,33 +7,40 @@ import (
 
 // RSS represents an RSS 2.0 feed
 type RSS struct {
-	XMLName xml.Name `xml:"rss"`
-	Version string   `xml:"version,attr"`
-	AtomNS  string   `xml:"xmlns:atom,attr"`
-	Content string   `xml:"xmlns:content,attr"`
-	MediaNS string   `xml:"xmlns:media,attr"`
-	Channel Channel  `xml:"channel"`
+	XMLName   xml.Name `xml:"rss"`
+	Version   string   `xml:"version,attr"`
+	AtomNS    string   `xml:"xmlns:atom,attr"`
+	Content   string   `xml:"xmlns:content,attr"`
+	MediaNS   string   `xml:"xmlns:media,attr"`
+	DCNS      string   `xml:"xmlns:dc,attr,omitempty"`
+	ItunesNS  string   `xml:"xmlns:itunes,attr,omitempty"`
+	PodcastNS string   `xml:"xmlns:podcast,attr,omitempty"`
+	Channel   Channel  `xml:"channel"`
 }
 
 // Channel represents the RSS channel
 type Channel struct {
-	Title         string    `xml:"title"`
-	Link          string    `xml:"link"`
-	Description   string    `xml:"description"`
-	Language      string    `xml:"language,omitempty"`
-	PubDate       string    `xml:"pubDate,omitempty"`
-	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
-	TTL           int       `xml:"ttl,omitempty"`
-	AtomLink      *AtomLink `xml:"atom:link,omitempty"`
-	Image         *Image    `xml:"image,omitempty"`
-	Items         []RSSItem `xml:"item"`
+	Title          string    `xml:"title"`
+	Link           string    `xml:"link"`
+	Description    string    `xml:"description"`
+	Language       string    `xml:"language,omitempty"`
+	PubDate        string    `xml:"pubDate,omitempty"`
+	LastBuildDate  string    `xml:"lastBuildDate,omitempty"`
+	TTL            int       `xml:"ttl,omitempty"`
+	ManagingEditor string    `xml:"managingEditor,omitempty"`
+	Creator        []string  `xml:"dc:creator,omitempty"`
+	AtomLink       *AtomLink `xml:"atom:link,omitempty"`
+	Image          *Image    `xml:"image,omitempty"`
+	ItunesChannelElements
+	Items []RSSItem `xml:"item"`
 }
 
 // AtomLink represents an Atom link element in RSS
 type AtomLink struct {
-	Href string `xml:"href,attr"`
-	Rel  string `xml:"rel,attr"`
-	Type string `xml:"type,attr"`
+	Href  string `xml:"href,attr"`
+	Rel   string `xml:"rel,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
 }
 
 // Image represents the channel image
@@ -45,16 +52,21 @@ type Image struct {
 
 // RSSItem represents a single RSS item
 type RSSItem struct {
-	Title       string       `xml:"title"`
-	Link        string       `xml:"link"`
-	Description CDATA        `xml:"description"`
-	PubDate     string       `xml:"pubDate,omitempty"`
-	GUID        *GUID        `xml:"guid,omitempty"`
-	Author      string       `xml:"author,omitempty"`
-	Category    []string     `xml:"category,omitempty"`
-	Comments    string       `xml:"comments,omitempty"`
-	Enclosure   *Enclosure   `xml:"enclosure,omitempty"`
-	Content     *ContentHTML `xml:"content:encoded,omitempty"`
+	Title       string          `xml:"title"`
+	Link        string          `xml:"link"`
+	Description CDATA           `xml:"description"`
+	PubDate     string          `xml:"pubDate,omitempty"`
+	GUID        *GUID           `xml:"guid,omitempty"`
+	Author      string          `xml:"author,omitempty"`
+	Creator     []string        `xml:"dc:creator,omitempty"`
+	Category    []string        `xml:"category,omitempty"`
+	Comments    string          `xml:"comments,omitempty"`
+	Enclosure   *Enclosure      `xml:"enclosure,omitempty"`
+	Content     *ContentHTML    `xml:"content:encoded,omitempty"`
+	Torrent     *TorrentElement `xml:"torrent,omitempty"`
+	AtomLinks   []AtomLink      `xml:"atom:link,omitempty"`
+	MediaElements
+	ItunesItemElements
 }
 
 // GUID represents the GUID element
@@ -80,13 +92,23 @@ type CDATA struct {
 	Value string `xml:",cdata"`
 }
 
+func init() {
+	RegisterFormat(Format{
+		Name:        "rss",
+		ContentType: "application/rss+xml; charset=utf-8",
+		Extension:   "rss",
+		Stylesheet:  true,
+		Generate:    GenerateRSS,
+	})
+}
+
 // GenerateRSS converts Data to RSS 2.0 XML format
 func GenerateRSS(data *Data, currentURL string) (string, error) {
 	rss := RSS{
 		Version: "2.0",
 		AtomNS:  "http://www.w3.org/2005/Atom",
 		Content: "http://purl.org/rss/1.0/modules/content/",
-		MediaNS: "http://search.yahoo.com/mrss/",
+		MediaNS: mediaNamespace,
 		Channel: Channel{
 			Title:       data.Title,
 			Link:        data.Link,
@@ -111,6 +133,9 @@ func GenerateRSS(data *Data, currentURL string) (string, error) {
 		rss.Channel.LastBuildDate = formatRFC822(data.PubDate)
 	}
 
+	// Set authors
+	rss.Channel.ManagingEditor, rss.Channel.Creator = rssAuthors(data.AllAuthors())
+
 	// Set image
 	if data.Image != "" {
 		rss.Channel.Image = &Image{
@@ -120,14 +145,22 @@ func GenerateRSS(data *Data, currentURL string) (string, error) {
 		}
 	}
 
+	// Set podcast metadata
+	if isPodcast(data) {
+		rss.ItunesNS = itunesNamespace
+		rss.Channel.ItunesChannelElements = buildItunesChannelElements(data)
+	}
+	if hasPodcastExtensions(data.Item) {
+		rss.PodcastNS = podcastNamespace
+	}
+
 	// Convert items
 	rss.Channel.Items = make([]RSSItem, len(data.Item))
 	for i, item := range data.Item {
 		rssItem := RSSItem{
 			Title:       item.Title,
 			Link:        item.Link,
-			Description: CDATA{Value: item.Description},
-			Author:      item.Author,
+			Description: CDATA{Value: item.SummaryText()},
 			Category:    item.Category,
 			Comments:    item.Comments,
 		}
@@ -147,21 +180,58 @@ func GenerateRSS(data *Data, currentURL string) (string, error) {
 			rssItem.PubDate = formatRFC822(item.PubDate)
 		}
 
-		// Set enclosure
-		if item.EnclosureURL != "" {
+		// Set authors
+		rssItem.Author, rssItem.Creator = rssAuthors(item.AllAuthors())
+
+		// Set enclosure, RSS allows only one per item
+		if attachments := item.AllAttachments(); len(attachments) > 0 {
 			rssItem.Enclosure = &Enclosure{
-				URL:    item.EnclosureURL,
-				Type:   item.EnclosureType,
-				Length: item.EnclosureLength,
+				URL:    attachments[0].URL,
+				Type:   attachments[0].Type,
+				Length: attachments[0].Length,
 			}
 		}
 
-		// Set content:encoded if description is HTML
-		if item.Description != "" {
-			rssItem.Content = &ContentHTML{Value: item.Description}
+		// Set typed links
+		for _, link := range item.Links {
+			rssItem.AtomLinks = append(rssItem.AtomLinks, AtomLink{
+				Href:  link.Href,
+				Rel:   link.Rel,
+				Type:  link.Type,
+				Title: link.Title,
+			})
 		}
 
+		// Set torrent, exposing it as the enclosure for BT clients if none is set
+		if item.Torrent != nil && item.Torrent.Link != "" {
+			rssItem.Torrent = buildTorrentElement(item.Torrent)
+			if rssItem.Enclosure == nil {
+				rssItem.Enclosure = &Enclosure{
+					URL:    item.Torrent.Link,
+					Type:   torrentMIMEType,
+					Length: item.Torrent.ContentLength,
+				}
+			}
+		}
+
+		// Set the full content in content:encoded, the description holds the summary
+		if content := item.HTML(); content != "" {
+			rssItem.Content = &ContentHTML{Value: content}
+		}
+
+		// Set Media RSS elements
+		rssItem.MediaElements = buildMediaElements(item.Media)
+
+		// Set iTunes and Podcasting 2.0 elements
+		rssItem.ItunesItemElements = buildItunesItemElements(&item)
+
 		rss.Channel.Items[i] = rssItem
+		if len(rssItem.Creator) > 0 {
+			rss.DCNS = dcNamespace
+		}
+	}
+	if len(rss.Channel.Creator) > 0 {
+		rss.DCNS = dcNamespace
 	}
 
 	// Marshal to XML
@@ -170,10 +240,29 @@ func GenerateRSS(data *Data, currentURL string) (string, error) {
 		return "", err
 	}
 
-	return xml.Header + string(output), nil
+	return xml.Header + stylesheetInstruction(data.Stylesheet) + string(output), nil
+}
+
+// rssAuthors splits authors following the RSS conventions: the first author
+// with an email address goes to author (or managingEditor), the names of the
+// others to dc:creator
+func rssAuthors(authors []Person) (string, []string) {
+	var author string
+	var creators []string
+	for _, person := range authors {
+		if author == "" && person.Email != "" {
+			author = formatRSSPerson(person)
+			continue
+		}
+		if name := person.DisplayName(); name != "" {
+			creators = append(creators, name)
+		}
+	}
+	return author, creators
 }
 
-// formatRFC822 formats a time.Time to RFC822 format (RSS date format)
+// formatRFC822 formats a time.Time to RFC822 format (RSS date format) in
+// its own timezone
 func formatRFC822(t time.Time) string {
-	return t.UTC().Format(time.RFC1123Z)
+	return t.Format(time.RFC1123Z)
 }
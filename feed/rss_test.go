@@ -68,8 +68,8 @@ func TestGenerateRSS(t *testing.T) {
 	if item.Title != "Item 1" {
 		t.Errorf("Expected 'Item 1', got '%s'", item.Title)
 	}
-	if item.Author != "John Doe" {
-		t.Errorf("Expected 'John Doe', got '%s'", item.Author)
+	if item.Author != "" || !strings.Contains(output, "<dc:creator>John Doe</dc:creator>") {
+		t.Errorf("Expected author without email as dc:creator, got '%s'", item.Author)
 	}
 	if len(item.Category) != 2 {
 		t.Errorf("Expected 2 categories, got %d", len(item.Category))
@@ -171,6 +171,156 @@ func TestGenerateRSS_WithImage(t *testing.T) {
 	}
 }
 
+func TestGenerateRSS_WithMedia(t *testing.T) {
+	data := &Data{
+		Title: "Feed with Media",
+		Link:  "https://example.com",
+		Item: []Item{
+			{
+				Title: "Video",
+				Link:  "https://example.com/video",
+				Media: &Media{
+					Content: &MediaContent{
+						URL:      "https://example.com/video.mp4",
+						Type:     "video/mp4",
+						Medium:   "video",
+						Duration: 125,
+					},
+					Thumbnail: &MediaThumbnail{
+						URL:    "https://example.com/thumb.jpg",
+						Width:  480,
+						Height: 360,
+					},
+					Title:  "Video title",
+					Credit: "Jane Doe",
+				},
+			},
+		},
+	}
+
+	output, err := GenerateRSS(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateRSS failed: %v", err)
+	}
+
+	expected := []string{
+		`xmlns:media="http://search.yahoo.com/mrss/"`,
+		`<media:content url="https://example.com/video.mp4" type="video/mp4" medium="video" duration="125">`,
+		`<media:thumbnail url="https://example.com/thumb.jpg" width="480" height="360">`,
+		`<media:title type="plain">Video title</media:title>`,
+		`<media:credit>Jane Doe</media:credit>`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %s", want)
+		}
+	}
+	if strings.Contains(output, "media:description") {
+		t.Error("Did not expect empty media:description")
+	}
+}
+
+func TestGenerateRSS_MediaPlayer(t *testing.T) {
+	data := &Data{
+		Title: "Videos",
+		Link:  "https://example.com",
+		Item: []Item{
+			{
+				Title: "Video",
+				Link:  "https://example.com/video",
+				Media: &Media{
+					Player: &MediaPlayer{
+						URL:    "https://example.com/embed/video",
+						Width:  640,
+						Height: 360,
+					},
+				},
+			},
+		},
+	}
+
+	output, err := GenerateRSS(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateRSS failed: %v", err)
+	}
+
+	if !strings.Contains(output, `<media:player url="https://example.com/embed/video" width="640" height="360">`) {
+		t.Errorf("Expected media:player in output: %s", output)
+	}
+	if strings.Contains(output, "<enclosure") || strings.Contains(output, "media:content") {
+		t.Errorf("Did not expect the player as an enclosure or media:content: %s", output)
+	}
+}
+
+func TestGenerateRSS_Podcast(t *testing.T) {
+	data := &Data{
+		Title:          "Podcast",
+		Link:           "https://example.com",
+		Image:          "https://example.com/cover.jpg",
+		ItunesAuthor:   "Jane Doe",
+		ItunesCategory: "Technology > Tech News",
+		ItunesOwner:    &ItunesOwner{Name: "Jane Doe", Email: "jane@example.com"},
+		Item: []Item{
+			{
+				Title:          "Episode 1",
+				Link:           "https://example.com/ep1",
+				EnclosureURL:   "https://example.com/ep1.mp3",
+				EnclosureType:  "audio/mpeg",
+				ItunesDuration: 3725,
+				ItunesEpisode:  1,
+				ItunesSeason:   2,
+				Transcripts: []Transcript{
+					{URL: "https://example.com/ep1.vtt", Type: "text/vtt", Language: "en"},
+				},
+				Chapters: &Chapters{URL: "https://example.com/ep1.json", Type: "application/json+chapters"},
+			},
+		},
+	}
+
+	output, err := GenerateRSS(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateRSS failed: %v", err)
+	}
+
+	expected := []string{
+		`xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`,
+		`xmlns:podcast="https://podcastindex.org/namespace/1.0"`,
+		`<itunes:author>Jane Doe</itunes:author>`,
+		`<itunes:category text="Technology">`,
+		`<itunes:category text="Tech News"></itunes:category>`,
+		`<itunes:explicit>false</itunes:explicit>`,
+		`<itunes:image href="https://example.com/cover.jpg"></itunes:image>`,
+		`<itunes:email>jane@example.com</itunes:email>`,
+		`<itunes:duration>01:02:05</itunes:duration>`,
+		`<itunes:episode>1</itunes:episode>`,
+		`<itunes:season>2</itunes:season>`,
+		`<podcast:transcript url="https://example.com/ep1.vtt" type="text/vtt" language="en">`,
+		`<podcast:chapters url="https://example.com/ep1.json" type="application/json+chapters">`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %s", want)
+		}
+	}
+}
+
+func TestGenerateRSS_NotPodcast(t *testing.T) {
+	data := &Data{
+		Title: "Blog",
+		Link:  "https://example.com",
+		Item:  []Item{{Title: "Post", Link: "https://example.com/post"}},
+	}
+
+	output, err := GenerateRSS(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateRSS failed: %v", err)
+	}
+
+	if strings.Contains(output, "itunes") || strings.Contains(output, "podcast") {
+		t.Error("Expected no podcast namespaces for a regular feed")
+	}
+}
+
 func TestFormatRFC822(t *testing.T) {
 	// Test RFC822 date formatting
 	testTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
@@ -184,3 +334,134 @@ func TestFormatRFC822(t *testing.T) {
 		t.Errorf("Expected month Jan in formatted date: %s", formatted)
 	}
 }
+
+func TestGenerateRSS_Stylesheet(t *testing.T) {
+	data := &Data{
+		Title:      "Test Feed",
+		Link:       "https://example.com",
+		Stylesheet: "/feed.xsl",
+	}
+
+	output, err := GenerateRSS(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateRSS failed: %v", err)
+	}
+
+	pi := `<?xml-stylesheet type="text/xsl" href="/feed.xsl"?>`
+	if !strings.Contains(output, pi) {
+		t.Error("Expected xml-stylesheet processing instruction")
+	}
+	if strings.Index(output, pi) > strings.Index(output, "<rss") {
+		t.Error("Expected processing instruction before the root element")
+	}
+
+	// Output stays a valid feed
+	if _, err := Parse([]byte(output)); err != nil {
+		t.Errorf("Parse failed: %v", err)
+	}
+
+	data.Stylesheet = ""
+	output, _ = GenerateRSS(data, "https://example.com/feed")
+	if strings.Contains(output, "xml-stylesheet") {
+		t.Error("Expected no processing instruction without stylesheet")
+	}
+}
+
+func TestGenerateRSS_SummaryAndContent(t *testing.T) {
+	data := &Data{
+		Title: "Test Feed",
+		Link:  "https://example.com",
+		Item: []Item{
+			{Title: "Both", Summary: "Short summary", ContentHTML: "<p>Full content</p>"},
+			{Title: "Derived", ContentHTML: "<p>Only <b>HTML</b></p>"},
+			{Title: "Summary only", Summary: "Just a summary"},
+		},
+	}
+
+	output, err := GenerateRSS(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateRSS failed: %v", err)
+	}
+
+	var rss RSS
+	if err := xml.Unmarshal([]byte(output), &rss); err != nil {
+		t.Fatalf("Invalid XML: %v", err)
+	}
+
+	items := rss.Channel.Items
+	if items[0].Description.Value != "Short summary" {
+		t.Errorf("Expected summary in description, got %q", items[0].Description.Value)
+	}
+	if items[1].Description.Value != "Only HTML" {
+		t.Errorf("Expected derived plain-text summary, got %q", items[1].Description.Value)
+	}
+	if items[2].Description.Value != "Just a summary" {
+		t.Errorf("Expected explicit summary, got %q", items[2].Description.Value)
+	}
+
+	// Full content goes to content:encoded
+	if !strings.Contains(output, "<content:encoded><![CDATA[<p>Full content</p>]]></content:encoded>") {
+		t.Error("Expected full content in content:encoded")
+	}
+	if strings.Count(output, "<content:encoded>") != 2 {
+		t.Error("Expected no content:encoded for items without content")
+	}
+}
+
+func TestGenerateRSS_Authors(t *testing.T) {
+	data := &Data{
+		Title:   "Test Feed",
+		Link:    "https://example.com",
+		Authors: []Person{{Name: "Editor", Email: "editor@example.com"}},
+		Item: []Item{
+			{
+				Title: "Item",
+				Authors: []Person{
+					{Name: "Jane Doe", Email: "jane@example.com"},
+					{Name: "John Doe", URL: "https://example.com/john"},
+				},
+				Attachments: []Attachment{
+					{URL: "https://example.com/a.mp3", Type: "audio/mpeg", Length: 100},
+					{URL: "https://example.com/b.mp3", Type: "audio/mpeg", Length: 200},
+				},
+				Links: []Link{{Href: "https://example.com/replies", Rel: "replies", Type: "text/html"}},
+			},
+		},
+	}
+
+	output, err := GenerateRSS(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateRSS failed: %v", err)
+	}
+
+	var rss RSS
+	if err := xml.Unmarshal([]byte(output), &rss); err != nil {
+		t.Fatalf("Invalid XML: %v", err)
+	}
+
+	if rss.Channel.ManagingEditor != "editor@example.com (Editor)" {
+		t.Errorf("Wrong managingEditor: %s", rss.Channel.ManagingEditor)
+	}
+
+	item := rss.Channel.Items[0]
+	if item.Author != "jane@example.com (Jane Doe)" {
+		t.Errorf("Expected first author with email, got '%s'", item.Author)
+	}
+	if item.Enclosure == nil || item.Enclosure.URL != "https://example.com/a.mp3" {
+		t.Error("Expected the first attachment as enclosure")
+	}
+
+	expected := []string{
+		`xmlns:dc="http://purl.org/dc/elements/1.1/"`,
+		"<dc:creator>John Doe</dc:creator>",
+		`<atom:link href="https://example.com/replies" rel="replies" type="text/html"></atom:link>`,
+	}
+	for _, s := range expected {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected output to contain %q", s)
+		}
+	}
+	if strings.Count(output, "<enclosure ") != 1 {
+		t.Error("Expected a single enclosure")
+	}
+}
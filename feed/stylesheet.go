@@ -0,0 +1,26 @@
+package feed
+
+import (
+	_ "embed"
+	"html"
+)
+
+// StylesheetXSLT renders RSS 2.0 and Atom feeds as HTML in browsers
+//
+//go:embed templates/feed.xsl
+var StylesheetXSLT []byte
+
+// StylesheetContentSecurityPolicy is sent with RSS and Atom output when the
+// stylesheet is enabled, since browsers render the feed as a page of the
+// GRSS origin. Loading the stylesheet counts as a script in some browsers.
+const StylesheetContentSecurityPolicy = "default-src 'none'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+// stylesheetInstruction returns the xml-stylesheet processing instruction
+// referencing href, or an empty string when no stylesheet is set
+func stylesheetInstruction(href string) string {
+	if href == "" {
+		return ""
+	}
+	return `<?xml-stylesheet type="text/xsl" href="` + html.EscapeString(href) + `"?>` + "\n"
+}
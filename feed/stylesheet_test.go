@@ -0,0 +1,15 @@
+package feed
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestStylesheetXSLT_Links(t *testing.T) {
+	// Links from feed data go through the link template, which checks their
+	// scheme
+	hrefs := regexp.MustCompile(`href="\{[^}]*\}"`).FindAllString(string(StylesheetXSLT), -1)
+	if len(hrefs) != 1 || hrefs[0] != `href="{$href}"` {
+		t.Errorf("Expected links to be rendered by the link template only, got %v", hrefs)
+	}
+}
@@ -0,0 +1,126 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<xsl:stylesheet version="1.0"
+  xmlns:xsl="http://www.w3.org/1999/XSL/Transform"
+  xmlns:atom="http://www.w3.org/2005/Atom"
+  exclude-result-prefixes="atom">
+  <xsl:output method="html" encoding="UTF-8" indent="yes" doctype-system="about:legacy-compat"/>
+
+  <xsl:template match="/">
+    <html>
+      <head>
+        <meta charset="utf-8"/>
+        <meta name="viewport" content="width=device-width, initial-scale=1"/>
+        <title><xsl:value-of select="rss/channel/title | atom:feed/atom:title"/></title>
+        <style>
+          body { font-family: Arial, sans-serif; max-width: 800px; margin: 50px auto; padding: 20px; color: #333; }
+          a { color: #0366d6; text-decoration: none; }
+          a:hover { text-decoration: underline; }
+          .notice { background: #f6f8fa; padding: 10px; border-radius: 5px; margin: 10px 0; color: #666; }
+          .item { border-bottom: 1px solid #eee; padding: 15px 0; }
+          .item h2 { font-size: 1.2em; margin: 0 0 5px; }
+          .meta { color: #666; font-size: 0.9em; }
+          .category { background: #f1f8ff; border-radius: 3px; padding: 1px 6px; margin-right: 4px; }
+        </style>
+      </head>
+      <body>
+        <p class="notice">This is a web feed. Copy the URL from the address bar into your feed reader to subscribe.</p>
+        <xsl:apply-templates select="rss/channel | atom:feed"/>
+      </body>
+    </html>
+  </xsl:template>
+
+  <!-- RSS 2.0 -->
+  <xsl:template match="channel">
+    <h1>
+      <xsl:call-template name="link">
+        <xsl:with-param name="href" select="string(link)"/>
+        <xsl:with-param name="text" select="string(title)"/>
+      </xsl:call-template>
+    </h1>
+    <p><xsl:value-of select="description"/></p>
+    <xsl:for-each select="item">
+      <div class="item">
+        <h2>
+          <xsl:call-template name="link">
+            <xsl:with-param name="href" select="string(link)"/>
+            <xsl:with-param name="text" select="string(title)"/>
+          </xsl:call-template>
+        </h2>
+        <div class="meta">
+          <xsl:value-of select="pubDate"/>
+          <xsl:if test="author">
+            <xsl:text> · </xsl:text><xsl:value-of select="author"/>
+          </xsl:if>
+        </div>
+        <xsl:if test="category">
+          <p>
+            <xsl:for-each select="category">
+              <span class="category"><xsl:value-of select="."/></span>
+            </xsl:for-each>
+          </p>
+        </xsl:if>
+        <xsl:for-each select="enclosure">
+          <p>📎 <xsl:call-template name="link">
+            <xsl:with-param name="href" select="string(@url)"/>
+            <xsl:with-param name="text" select="string(@type)"/>
+          </xsl:call-template></p>
+        </xsl:for-each>
+      </div>
+    </xsl:for-each>
+  </xsl:template>
+
+  <!-- Atom 1.0 -->
+  <xsl:template match="atom:feed">
+    <h1>
+      <xsl:call-template name="link">
+        <xsl:with-param name="href" select="string(atom:link[@rel='alternate']/@href)"/>
+        <xsl:with-param name="text" select="string(atom:title)"/>
+      </xsl:call-template>
+    </h1>
+    <p><xsl:value-of select="atom:subtitle"/></p>
+    <xsl:for-each select="atom:entry">
+      <div class="item">
+        <h2>
+          <xsl:call-template name="link">
+            <xsl:with-param name="href" select="string(atom:link[not(@rel) or @rel='alternate']/@href)"/>
+            <xsl:with-param name="text" select="string(atom:title)"/>
+          </xsl:call-template>
+        </h2>
+        <div class="meta">
+          <xsl:value-of select="atom:published | atom:updated[not(../atom:published)]"/>
+          <xsl:if test="atom:author">
+            <xsl:text> · </xsl:text><xsl:value-of select="atom:author/atom:name"/>
+          </xsl:if>
+        </div>
+        <xsl:if test="atom:category">
+          <p>
+            <xsl:for-each select="atom:category">
+              <span class="category"><xsl:value-of select="@term"/></span>
+            </xsl:for-each>
+          </p>
+        </xsl:if>
+        <xsl:for-each select="atom:link[@rel='enclosure']">
+          <p>📎 <xsl:call-template name="link">
+            <xsl:with-param name="href" select="string(@href)"/>
+            <xsl:with-param name="text" select="string(@type)"/>
+          </xsl:call-template></p>
+        </xsl:for-each>
+      </div>
+    </xsl:for-each>
+  </xsl:template>
+
+  <!-- Links from feed data are only rendered for HTTP(S) URLs, so that a
+       javascript: link cannot run on this origin -->
+  <xsl:template name="link">
+    <xsl:param name="href"/>
+    <xsl:param name="text"/>
+    <xsl:choose>
+      <xsl:when test="starts-with($href, 'http://') or starts-with($href, 'https://')">
+        <a href="{$href}"><xsl:value-of select="$text"/></a>
+      </xsl:when>
+      <xsl:otherwise>
+        <xsl:value-of select="$text"/>
+      </xsl:otherwise>
+    </xsl:choose>
+  </xsl:template>
+</xsl:stylesheet>
@@ -0,0 +1,100 @@
+package feed
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"net/url"
+	"strings"
+)
+
+const (
+	// torrentNamespace is the ezRSS torrent namespace URI
+	torrentNamespace = "http://xmlns.ezrss.it/0.1/"
+
+	// torrentMIMEType is the MIME type used for torrent enclosures
+	torrentMIMEType = "application/x-bittorrent"
+)
+
+// TorrentElement represents the ezRSS torrent element
+type TorrentElement struct {
+	Xmlns         string `xml:"xmlns,attr"`
+	FileName      string `xml:"fileName,omitempty"`
+	Link          string `xml:"link,omitempty"`
+	ContentLength int64  `xml:"contentLength,omitempty"`
+	PubDate       string `xml:"pubDate,omitempty"`
+	InfoHash      string `xml:"infoHash,omitempty"`
+	MagnetURI     *CDATA `xml:"magnetURI,omitempty"`
+}
+
+// buildTorrentElement converts Torrent to its ezRSS element
+func buildTorrentElement(torrent *Torrent) *TorrentElement {
+	if torrent == nil || torrent.Link == "" {
+		return nil
+	}
+
+	infoHash, magnetURI := torrentIdentity(torrent)
+	element := &TorrentElement{
+		Xmlns:         torrentNamespace,
+		FileName:      torrent.FileName,
+		Link:          torrent.Link,
+		ContentLength: torrent.ContentLength,
+		InfoHash:      infoHash,
+	}
+
+	if !torrent.PubDate.IsZero() {
+		element.PubDate = formatRFC822(torrent.PubDate)
+	}
+	if magnetURI != "" {
+		element.MagnetURI = &CDATA{Value: magnetURI}
+	}
+
+	return element
+}
+
+// torrentIdentity returns the info hash and magnet URI of a torrent, deriving
+// whichever one the route did not provide from the other
+func torrentIdentity(torrent *Torrent) (string, string) {
+	infoHash := strings.ToLower(torrent.InfoHash)
+	magnetURI := torrent.MagnetURI
+
+	if magnetURI == "" && strings.HasPrefix(torrent.Link, "magnet:") {
+		magnetURI = torrent.Link
+	}
+	if infoHash == "" && magnetURI != "" {
+		infoHash, _ = ParseMagnetInfoHash(magnetURI)
+	}
+	if magnetURI == "" && infoHash != "" {
+		magnetURI = "magnet:?xt=urn:btih:" + infoHash
+	}
+
+	return infoHash, magnetURI
+}
+
+// ParseMagnetInfoHash extracts the BitTorrent info hash from a magnet URI and
+// returns it as lowercase hex. Both hex and base32 encoded hashes are supported.
+func ParseMagnetInfoHash(magnetURI string) (string, bool) {
+	u, err := url.Parse(magnetURI)
+	if err != nil || u.Scheme != "magnet" {
+		return "", false
+	}
+
+	for _, xt := range u.Query()["xt"] {
+		if !strings.HasPrefix(strings.ToLower(xt), "urn:btih:") {
+			continue
+		}
+
+		hash := xt[len("urn:btih:"):]
+		switch len(hash) {
+		case 40:
+			if _, err := hex.DecodeString(hash); err == nil {
+				return strings.ToLower(hash), true
+			}
+		case 32:
+			if decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash)); err == nil {
+				return hex.EncodeToString(decoded), true
+			}
+		}
+	}
+
+	return "", false
+}
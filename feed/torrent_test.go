@@ -0,0 +1,96 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMagnetInfoHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		magnet   string
+		expected string
+		ok       bool
+	}{
+		{"hex hash", "magnet:?xt=urn:btih:C12FE1C06BBA254A9DC9F519B335AA7C1367A88A&dn=file", "c12fe1c06bba254a9dc9f519b335aa7c1367a88a", true},
+		{"base32 hash", "magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK", "c12fe1c06bba254a9dc9f519b335aa7c1367a88a", true},
+		{"no btih", "magnet:?xt=urn:sha1:abc", "", false},
+		{"not a magnet", "https://example.com/file.torrent", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, ok := ParseMagnetInfoHash(tt.magnet)
+			if ok != tt.ok || hash != tt.expected {
+				t.Errorf("ParseMagnetInfoHash(%s) = (%s, %v), want (%s, %v)", tt.magnet, hash, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestGenerateRSS_WithTorrent(t *testing.T) {
+	data := &Data{
+		Title: "Feed with Torrent",
+		Link:  "https://example.com",
+		Item: []Item{
+			{
+				Title: "Release",
+				Link:  "https://example.com/release",
+				Torrent: &Torrent{
+					Link:          "magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK",
+					ContentLength: 1024,
+					PubDate:       time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	output, err := GenerateRSS(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateRSS failed: %v", err)
+	}
+
+	expected := []string{
+		`<torrent xmlns="http://xmlns.ezrss.it/0.1/">`,
+		`<contentLength>1024</contentLength>`,
+		`<infoHash>c12fe1c06bba254a9dc9f519b335aa7c1367a88a</infoHash>`,
+		`<magnetURI><![CDATA[magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK]]></magnetURI>`,
+		`type="application/x-bittorrent" length="1024"`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %s", want)
+		}
+	}
+}
+
+func TestGenerateAtomAndJSON_WithTorrent(t *testing.T) {
+	data := &Data{
+		Title: "Feed with Torrent",
+		Link:  "https://example.com",
+		Item: []Item{
+			{
+				Title:   "Release",
+				Link:    "https://example.com/release",
+				Torrent: &Torrent{Link: "https://example.com/release.torrent", ContentLength: 2048},
+			},
+		},
+	}
+
+	atom, err := GenerateAtom(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateAtom failed: %v", err)
+	}
+	if !strings.Contains(atom, `<link href="https://example.com/release.torrent" rel="enclosure" type="application/x-bittorrent" length="2048">`) {
+		t.Error("Expected torrent enclosure link in Atom output")
+	}
+
+	json, err := GenerateJSON(data, "https://example.com/feed")
+	if err != nil {
+		t.Fatalf("GenerateJSON failed: %v", err)
+	}
+	if !strings.Contains(json, `"mime_type": "application/x-bittorrent"`) {
+		t.Error("Expected torrent attachment in JSON output")
+	}
+}
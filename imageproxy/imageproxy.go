@@ -0,0 +1,215 @@
+// Package imageproxy serves upstream images and media through GRSS, for
+// sources that block hotlinking or that readers cannot reach directly.
+// Proxied URLs are signed with ACCESS_KEY so the endpoint is not an open proxy.
+package imageproxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/cache"
+	"github.com/jean-jacket/grss/client"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/utils"
+)
+
+// Path is where the proxy endpoint is served
+const Path = "/proxy/image"
+
+// cacheEntryLimit is the maximum size in bytes of a cached upstream response
+const cacheEntryLimit = 1 << 20
+
+// newClient creates the client fetching upstream media. Proxied URLs come from
+// item content, so it refuses to connect to private addresses.
+var newClient = client.NewPublic
+
+// Sign returns the signature of a URL, or an empty string when no access key
+// is configured
+func Sign(rawURL string) string {
+	if config.C.AccessKey == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(config.C.AccessKey))
+	mac.Write([]byte(rawURL))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// URL returns the signed proxy URL of rawURL on the server at baseURL
+func URL(baseURL, rawURL string) string {
+	return baseURL + Path + "?url=" + url.QueryEscape(rawURL) + "&sig=" + Sign(rawURL)
+}
+
+// verify reports whether sig is the signature of rawURL
+func verify(rawURL, sig string) bool {
+	expected := Sign(rawURL)
+	return expected != "" && hmac.Equal([]byte(expected), []byte(sig))
+}
+
+// Handler streams the upstream media of a signed URL. Responses up to
+// cacheEntryLimit are stored in c for CACHE_CONTENT_EXPIRE; c may be nil.
+func Handler(c cache.Cache) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if config.C.AccessKey == "" {
+			abortWithError(ctx, http.StatusNotFound, "Image proxy requires ACCESS_KEY")
+			return
+		}
+
+		rawURL := ctx.Query("url")
+		if !verify(rawURL, ctx.Query("sig")) {
+			abortWithError(ctx, http.StatusForbidden, "Invalid signature")
+			return
+		}
+		if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			abortWithError(ctx, http.StatusBadRequest, "Invalid URL")
+			return
+		}
+
+		// Serve from cache
+		cacheKey := fmt.Sprintf("grss:image:%x", sha256.Sum256([]byte(rawURL)))
+		if c != nil {
+			if cached, err := c.Get(ctx.Request.Context(), cacheKey); err == nil && cached != "" {
+				if contentType, body, ok := strings.Cut(cached, "\n"); ok {
+					ctx.Header("GRSS-Cache-Status", "HIT")
+					writeHeaders(ctx, contentType, int64(len(body)))
+					ctx.String(http.StatusOK, body)
+					return
+				}
+			}
+		}
+
+		resp, err := newClient(config.C).GetStream(rawURL, nil)
+		if err != nil {
+			utils.LogError("Failed to proxy %s: %v", rawURL, err)
+			abortWithError(ctx, http.StatusBadGateway, "Failed to fetch upstream media")
+			return
+		}
+		defer resp.Body.Close()
+
+		maxSize := config.C.ImageProxy.MaxSize
+		if maxSize > 0 && resp.ContentLength > maxSize {
+			abortWithError(ctx, http.StatusRequestEntityTooLarge, "Upstream media exceeds the size limit")
+			return
+		}
+
+		// Check the content type, sniffing it when the upstream omits it
+		body := bufio.NewReader(resp.Body)
+		contentType := mediaType(resp.Header.Get("Content-Type"))
+		if contentType == "" || contentType == "application/octet-stream" {
+			head, _ := body.Peek(512)
+			contentType = mediaType(http.DetectContentType(head))
+		}
+		if !allowedType(contentType) {
+			abortWithError(ctx, http.StatusUnsupportedMediaType, "Unsupported media type: "+contentType)
+			return
+		}
+
+		ctx.Header("GRSS-Cache-Status", "MISS")
+		writeHeaders(ctx, contentType, resp.ContentLength)
+		ctx.Status(http.StatusOK)
+
+		// Stream the body, keeping a copy of small responses for the cache
+		var buffer bytes.Buffer
+		reader := io.Reader(body)
+		if maxSize > 0 {
+			reader = io.LimitReader(body, maxSize)
+		}
+		if c != nil {
+			reader = io.TeeReader(reader, &limitedBuffer{buffer: &buffer, limit: cacheEntryLimit})
+		}
+		written, err := io.Copy(ctx.Writer, reader)
+		if err != nil {
+			utils.LogError("Failed to stream %s: %v", rawURL, err)
+			return
+		}
+
+		// Abort responses that turn out larger than the limit
+		if maxSize > 0 && written == maxSize {
+			if _, err := body.ReadByte(); err == nil {
+				utils.LogError("Proxied media %s exceeds the size limit", rawURL)
+				panic(http.ErrAbortHandler)
+			}
+		}
+
+		if c != nil && written <= cacheEntryLimit {
+			value := contentType + "\n" + buffer.String()
+			go func() {
+				if err := c.Set(context.Background(), cacheKey, value, config.C.Cache.ContentExpire); err != nil {
+					utils.LogError("Failed to cache proxied media: %v", err)
+				}
+			}()
+		}
+	}
+}
+
+// writeHeaders sets the response headers of proxied media. The CSP keeps
+// scripts in SVG images from running on the GRSS origin.
+func writeHeaders(ctx *gin.Context, contentType string, length int64) {
+	ctx.Header("Content-Type", contentType)
+	if length >= 0 {
+		ctx.Header("Content-Length", strconv.FormatInt(length, 10))
+	}
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(config.C.Cache.ContentExpire.Seconds())))
+	ctx.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+}
+
+// mediaType returns the media type of a Content-Type header without parameters
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// allowedType reports whether a media type matches one of the allowed prefixes
+func allowedType(mediaType string) bool {
+	if mediaType == "" {
+		return false
+	}
+	for _, prefix := range config.C.ImageProxy.AllowedTypes {
+		if prefix = strings.TrimSpace(prefix); prefix != "" && strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// abortWithError responds with a JSON error like the other endpoints
+func abortWithError(ctx *gin.Context, status int, message string) {
+	ctx.JSON(status, gin.H{
+		"error": gin.H{
+			"message": message,
+		},
+	})
+	ctx.Abort()
+}
+
+// limitedBuffer is a writer that keeps at most limit bytes and discards the rest
+type limitedBuffer struct {
+	buffer *bytes.Buffer
+	limit  int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buffer.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buffer.Write(p[:remaining])
+		} else {
+			b.buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
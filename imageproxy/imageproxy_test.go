@@ -0,0 +1,194 @@
+package imageproxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/cache"
+	"github.com/jean-jacket/grss/client"
+	"github.com/jean-jacket/grss/config"
+)
+
+var pngHeader = "\x89PNG\r\n\x1a\n"
+
+func newTestUpstream(fetches *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		switch r.URL.Path {
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(pngHeader + "data"))
+		case "/untyped":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte(pngHeader + "data"))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html></html>"))
+		case "/large.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(pngHeader + strings.Repeat("x", 100)))
+		case "/chunked.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(pngHeader))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func newTestRouter(c cache.Cache) *gin.Engine {
+	config.C = &config.Config{AccessKey: "secret", RequestTimeout: 5 * time.Second}
+	config.C.Cache.ContentExpire = time.Hour
+	config.C.ImageProxy.MaxSize = 64
+	config.C.ImageProxy.AllowedTypes = []string{"image/"}
+
+	// The test upstreams listen on loopback
+	newClient = client.New
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(Path, Handler(c))
+	return router
+}
+
+func serve(router http.Handler, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestSign(t *testing.T) {
+	config.C = &config.Config{AccessKey: "secret"}
+
+	sig := Sign("https://example.com/a.png")
+	if sig == "" || sig == Sign("https://example.com/b.png") {
+		t.Errorf("Expected distinct signatures, got %q", sig)
+	}
+	if !verify("https://example.com/a.png", sig) || verify("https://example.com/b.png", sig) {
+		t.Error("Expected signature to verify only for its URL")
+	}
+
+	proxied := URL("https://grss.example.com", "https://example.com/a.png?x=1")
+	if proxied != "https://grss.example.com/proxy/image?url=https%3A%2F%2Fexample.com%2Fa.png%3Fx%3D1&sig="+Sign("https://example.com/a.png?x=1") {
+		t.Errorf("Wrong proxy URL: %s", proxied)
+	}
+
+	config.C.AccessKey = ""
+	if Sign("https://example.com/a.png") != "" || verify("https://example.com/a.png", "") {
+		t.Error("Expected signing to be disabled without access key")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var fetches atomic.Int32
+	upstream := newTestUpstream(&fetches)
+	defer upstream.Close()
+
+	router := newTestRouter(cache.NewMemoryCache(10))
+	proxied := func(path string) string {
+		return URL("", upstream.URL+path)
+	}
+
+	// Streams and caches the upstream image
+	w := serve(router, proxied("/image.png"))
+	if w.Code != http.StatusOK || w.Body.String() != pngHeader+"data" {
+		t.Fatalf("Expected proxied image, got %d: %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "image/png" || w.Header().Get("GRSS-Cache-Status") != "MISS" {
+		t.Errorf("Wrong headers: %v", w.Header())
+	}
+	if !strings.Contains(w.Header().Get("Content-Security-Policy"), "sandbox") {
+		t.Error("Expected sandboxing CSP")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	w = serve(router, proxied("/image.png"))
+	if w.Code != http.StatusOK || w.Header().Get("GRSS-Cache-Status") != "HIT" || w.Body.String() != pngHeader+"data" {
+		t.Errorf("Expected cached image, got %d %s", w.Code, w.Header().Get("GRSS-Cache-Status"))
+	}
+	if count := fetches.Load(); count != 1 {
+		t.Errorf("Expected 1 upstream fetch, got %d", count)
+	}
+
+	// Sniffs generic content types
+	if w := serve(router, proxied("/untyped")); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Expected sniffed image type, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{"invalid signature", Path + "?url=" + url.QueryEscape(upstream.URL+"/image.png") + "&sig=bad", http.StatusForbidden},
+		{"missing signature", Path + "?url=" + url.QueryEscape(upstream.URL+"/image.png"), http.StatusForbidden},
+		{"invalid scheme", URL("", "file:///etc/passwd"), http.StatusBadRequest},
+		{"disallowed type", proxied("/page.html"), http.StatusUnsupportedMediaType},
+		{"too large", proxied("/large.png"), http.StatusRequestEntityTooLarge},
+		{"upstream error", proxied("/missing"), http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(router, tt.target); w.Code != tt.status {
+				t.Errorf("Expected %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_StreamSizeLimit(t *testing.T) {
+	var fetches atomic.Int32
+	upstream := newTestUpstream(&fetches)
+	defer upstream.Close()
+
+	server := httptest.NewServer(newTestRouter(nil))
+	defer server.Close()
+
+	// Responses without a length are cut off at the limit
+	resp, err := http.Get(server.URL + URL("", upstream.URL+"/chunked.png"))
+	if err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err == nil {
+		t.Error("Expected oversized response to be aborted")
+	}
+}
+
+func TestHandler_Disabled(t *testing.T) {
+	router := newTestRouter(nil)
+	config.C.AccessKey = ""
+
+	if w := serve(router, Path+"?url=https%3A%2F%2Fexample.com%2Fa.png&sig="); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without access key, got %d", w.Code)
+	}
+}
+
+func TestHandler_PrivateAddress(t *testing.T) {
+	var fetches atomic.Int32
+	upstream := newTestUpstream(&fetches)
+	defer upstream.Close()
+
+	router := newTestRouter(nil)
+	newClient = client.NewPublic
+	defer func() { newClient = client.New }()
+
+	// Signed URLs of loopback and private hosts are not fetched
+	for _, target := range []string{upstream.URL + "/image.png", "http://10.0.0.1/image.png", "http://169.254.169.254/latest"} {
+		if w := serve(router, URL("", target)); w.Code != http.StatusBadGateway {
+			t.Errorf("%s: expected 502, got %d", target, w.Code)
+		}
+	}
+	if count := fetches.Load(); count != 0 {
+		t.Errorf("Expected no upstream fetch, got %d", count)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"crypto/md5"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/routes/registry"
 )
 
 var bypassPaths = map[string]bool{
@@ -14,6 +16,8 @@ var bypassPaths = map[string]bool{
 	"/robots.txt":  true,
 	"/favicon.ico": true,
 	"/healthz":     true,
+	"/feed.xsl":    true,
+	"/proxy/image": true, // Authenticated by URL signatures
 }
 
 // AccessControl middleware validates access key or code
@@ -43,9 +47,8 @@ func AccessControl() gin.HandlerFunc {
 			return
 		}
 
-		// Validate code (MD5 of path + access key)
-		expectedCode := fmt.Sprintf("%x", md5.Sum([]byte(path+config.C.AccessKey)))
-		if code == expectedCode {
+		// Validate code
+		if code == accessCode(c) {
 			c.Next()
 			return
 		}
@@ -59,3 +62,20 @@ func AccessControl() gin.HandlerFunc {
 		c.Abort()
 	}
 }
+
+// accessCode returns the code granting access to a request: the MD5 of the
+// path and the access key. For routes with CodeQueryParameters, the path is
+// followed by "?" and those parameters, URL-encoded and sorted by name.
+func accessCode(c *gin.Context) string {
+	message := c.Request.URL.Path
+	if route, ok := registry.LookupRoute(c.FullPath()); ok && len(route.CodeQueryParameters) > 0 {
+		query := url.Values{}
+		for _, name := range route.CodeQueryParameters {
+			if values := c.QueryArray(name); len(values) > 0 {
+				query[name] = values
+			}
+		}
+		message += "?" + query.Encode()
+	}
+	return fmt.Sprintf("%x", md5.Sum([]byte(message+config.C.AccessKey)))
+}
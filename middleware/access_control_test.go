@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/routes/registry"
+)
+
+func TestAccessControl_Code(t *testing.T) {
+	config.C = &config.Config{AccessKey: "secret"}
+	registry.RegisterRoute("codetest", registry.Route{
+		Path:                "/proxy",
+		CodeQueryParameters: []string{"url"},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(AccessControl())
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	router.GET("/codetest/feed", ok)
+	router.GET("/codetest/proxy", ok)
+
+	code := func(message string) string {
+		return fmt.Sprintf("%x", md5.Sum([]byte(message+"secret")))
+	}
+	feedURL := "https://example.com/feed.xml"
+	proxyCode := code("/codetest/proxy?url=" + url.QueryEscape(feedURL))
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/codetest/feed", http.StatusForbidden},
+		{"/codetest/feed?key=secret", http.StatusOK},
+		{"/codetest/feed?code=" + code("/codetest/feed"), http.StatusOK},
+		{"/codetest/feed?limit=5&code=" + code("/codetest/feed"), http.StatusOK},
+		{"/codetest/proxy?url=" + url.QueryEscape(feedURL) + "&code=" + proxyCode, http.StatusOK},
+		{"/codetest/proxy?url=" + url.QueryEscape(feedURL) + "&limit=5&code=" + proxyCode, http.StatusOK},
+		// A code for one URL does not grant another, nor the path alone
+		{"/codetest/proxy?url=" + url.QueryEscape("http://10.0.0.1/") + "&code=" + proxyCode, http.StatusForbidden},
+		{"/codetest/proxy?url=" + url.QueryEscape(feedURL) + "&code=" + code("/codetest/proxy"), http.StatusForbidden},
+		{"/codetest/proxy?url=" + url.QueryEscape(feedURL) + "&key=secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d", tt.target, tt.status, w.Code)
+		}
+	}
+}
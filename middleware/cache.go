@@ -3,47 +3,281 @@ package middleware
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jean-jacket/grss/cache"
 	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+	"github.com/jean-jacket/grss/routes/registry"
 	"github.com/jean-jacket/grss/utils"
 	"golang.org/x/sync/singleflight"
 )
 
 var sf singleflight.Group
 
-// Cache middleware provides caching with request deduplication
+// refreshBackoff is how long stale data is served without refreshing the
+// route after a refresh failed, so that a failing upstream is not fetched on
+// every request
+const refreshBackoff = time.Minute
+
+// authParams are never part of the cache key, even when a route declares them
+var authParams = map[string]bool{
+	"key":  true,
+	"code": true,
+}
+
+// errNoRouteData is returned to the requests waiting on a route handler that
+// did not set feed data, such as one that redirected
+var errNoRouteData = errors.New("route handler set no feed data")
+
+// routeEntry is the feed data of a route cached by RouteCache. It is fresh
+// until Expires and then served stale until the cache drops it
+// CACHE_STALE_EXPIRE later.
+type routeEntry struct {
+	Data    *feed.Data `json:"data"`
+	Expires time.Time  `json:"expires"`
+}
+
+// RouteCache middleware caches the feed data returned by route handlers for
+// CACHE_ROUTE_EXPIRE, keyed by path and the query parameters the route
+// declares. Every format and output parameter combination of a route is
+// served from one upstream fetch, and the middlewares registered before it
+// process the cached data on every request. It must be registered last,
+// right before the route handlers.
+//
+// For CACHE_STALE_EXPIRE after that, stale data is served right away while
+// the route refreshes in the background, and instead of an error when the
+// route fails. After a failure, the route is not refreshed again for
+// refreshBackoff.
+func RouteCache(c cache.Cache) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Skip caching if disabled, for built-in endpoints and unknown routes
+		if config.C.Cache.Type == "" || bypassPaths[ctx.Request.URL.Path] || ctx.FullPath() == "" {
+			ctx.Next()
+			return
+		}
+
+		cacheKey := routeDataCacheKey(ctx)
+
+		// Serve the cached data without running the handler
+		var stale *routeEntry
+		cached, err := c.Get(ctx.Request.Context(), cacheKey)
+		if err == nil && cached != "" {
+			if entry, err := decodeRouteEntry(cached); err == nil {
+				if time.Now().Before(entry.Expires) {
+					ctx.Header("GRSS-Cache-Status", "HIT")
+					ctx.Set(ContextKeyData, entry.Data)
+					ctx.Abort()
+					return
+				}
+				stale = entry
+			}
+		}
+
+		// Keep serving stale data while the last refresh failed recently
+		if stale != nil && refreshFailed(ctx.Request.Context(), c, cacheKey) {
+			serveStaleData(ctx, stale, `111 - "Revalidation Failed"`)
+			return
+		}
+
+		// Serve stale data while the route refreshes in the background. Routes
+		// mounted outside the registry refresh in the request instead.
+		if route, ok := registry.LookupRoute(ctx.FullPath()); ok && stale != nil && route.Handler != nil {
+			refreshRoute(ctx.Copy(), c, cacheKey, route)
+			serveStaleData(ctx, stale, `110 - "Response is Stale"`)
+			return
+		}
+
+		// Cache miss - use singleflight to prevent thundering herd. Headers
+		// must be set before the response is rendered.
+		ctx.Header("GRSS-Cache-Status", "MISS")
+		leader := false
+		result, err, _ := sf.Do(cacheKey, func() (interface{}, error) {
+			leader = true
+
+			// Hold back the response of the handler, which is replaced by
+			// stale data when it fails
+			writer := &bufferedWriter{ResponseWriter: ctx.Writer}
+			ctx.Writer = writer
+			ctx.Next()
+			ctx.Writer = writer.ResponseWriter
+
+			data, exists := ctx.Get(ContextKeyData)
+			if !exists {
+				if last := ctx.Errors.Last(); last != nil && stale != nil {
+					return nil, last.Err
+				}
+				writer.replay()
+				if last := ctx.Errors.Last(); last != nil {
+					return nil, last.Err
+				}
+				return nil, errNoRouteData
+			}
+
+			// Encode now, the middlewares modify the data afterwards
+			return storeRouteEntry(c, cacheKey, data.(*feed.Data), config.C.Cache.RouteExpire, config.C.Cache.StaleExpire, false)
+		})
+
+		switch {
+		case err == nil:
+			// Requests that waited on another one get their own copy of its
+			// data
+			if !leader {
+				entry, err := decodeRouteEntry(result.(string))
+				if err != nil {
+					ctx.Next()
+					return
+				}
+				ctx.Set(ContextKeyData, entry.Data)
+				ctx.Abort()
+			}
+		case errors.Is(err, errNoRouteData):
+			if !leader {
+				ctx.Next()
+			}
+		case stale != nil:
+			utils.LogError("Failed to refresh %s, serving stale data: %v", ctx.Request.URL.Path, err)
+			recordRefreshFailure(c, cacheKey, err)
+			serveStaleData(ctx, stale, `111 - "Revalidation Failed"`)
+		case !leader:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": gin.H{
+					"message": err.Error(),
+				},
+			})
+			ctx.Abort()
+		}
+	}
+}
+
+// refreshRoute runs the handler of a route in the background and caches its
+// feed data. Refreshes of the same key are deduplicated with the requests
+// that miss the cache.
+func refreshRoute(ctx *gin.Context, c cache.Cache, cacheKey string, route registry.Route) {
+	// The handler outlives the request
+	ctx.Request = ctx.Request.WithContext(context.WithoutCancel(ctx.Request.Context()))
+	ctx.Set(registry.ContextKeyLocation, route.Location())
+	expire, staleExpire := config.C.Cache.RouteExpire, config.C.Cache.StaleExpire
+	sf.DoChan(cacheKey, func() (interface{}, error) {
+		data, err := route.Handler(ctx)
+		if err != nil {
+			utils.LogError("Failed to refresh %s, serving stale data: %v", ctx.Request.URL.Path, err)
+			recordRefreshFailure(c, cacheKey, err)
+			return nil, err
+		}
+		return storeRouteEntry(c, cacheKey, data, expire, staleExpire, true)
+	})
+}
+
+// refreshFailureKey returns the cache key marking failed refreshes of the
+// feed data cached under cacheKey
+func refreshFailureKey(cacheKey string) string {
+	return cacheKey + ":failed"
+}
+
+// recordRefreshFailure marks the feed data cached under cacheKey as failing
+// to refresh for refreshBackoff
+func recordRefreshFailure(c cache.Cache, cacheKey string, err error) {
+	if err := c.Set(context.Background(), refreshFailureKey(cacheKey), err.Error(), refreshBackoff); err != nil {
+		utils.LogError("Failed to cache refresh failure: %v", err)
+	}
+}
+
+// refreshFailed reports whether the feed data cached under cacheKey failed
+// to refresh within refreshBackoff
+func refreshFailed(ctx context.Context, c cache.Cache, cacheKey string) bool {
+	failure, err := c.Get(ctx, refreshFailureKey(cacheKey))
+	return err == nil && failure != ""
+}
+
+// serveStaleData sets stale feed data in context with a warning header
+func serveStaleData(ctx *gin.Context, entry *routeEntry, warning string) {
+	ctx.Header("GRSS-Cache-Status", "STALE")
+	ctx.Header("Warning", warning)
+	ctx.Set(ContextKeyData, entry.Data)
+	ctx.Abort()
+}
+
+// storeRouteEntry caches the feed data of a route, fresh for expire and stale
+// for staleExpire after that, in the background unless wait is set, and
+// returns the encoded entry
+func storeRouteEntry(c cache.Cache, cacheKey string, data *feed.Data, expire, staleExpire time.Duration, wait bool) (interface{}, error) {
+	entry := routeEntry{Data: data, Expires: time.Now().Add(expire)}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		utils.LogError("Failed to encode feed data: %v", err)
+		return nil, errNoRouteData
+	}
+	response := string(encoded)
+
+	set := func() {
+		bgCtx := context.Background()
+		err := c.Set(bgCtx, cacheKey, response, expire+staleExpire)
+		if err != nil {
+			utils.LogError("Failed to cache feed data: %v", err)
+		}
+	}
+	if wait {
+		set()
+	} else {
+		go set()
+	}
+
+	return response, nil
+}
+
+// decodeRouteEntry decodes feed data cached by RouteCache
+func decodeRouteEntry(s string) (*routeEntry, error) {
+	entry := &routeEntry{}
+	if err := json.Unmarshal([]byte(s), entry); err != nil {
+		return nil, err
+	}
+	if entry.Data == nil {
+		return nil, errNoRouteData
+	}
+	return entry, nil
+}
+
+// Cache middleware caches rendered output for CACHE_OUTPUT_EXPIRE with
+// request deduplication. It is an optional tier in front of RouteCache that
+// also skips the filtering and rendering of repeated requests.
 func Cache(c cache.Cache) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		// Skip caching if disabled
-		if config.C.Cache.Type == "" {
+		// Skip caching if disabled and for built-in endpoints
+		if config.C.Cache.Type == "" || bypassPaths[ctx.Request.URL.Path] {
+			ctx.Next()
+			return
+		}
+
+		// Let the Template middleware reject unknown formats
+		format, err := negotiateFormat(ctx)
+		if err != nil {
 			ctx.Next()
 			return
 		}
 
 		// Generate cache key
-		path := ctx.Request.URL.Path
-		format := ctx.DefaultQuery("format", "rss")
-		limit := ctx.Query("limit")
-		keyData := fmt.Sprintf("%s:%s:%s", path, format, limit)
-		cacheKey := fmt.Sprintf("grss:cache:%x", sha256.Sum256([]byte(keyData)))
+		cacheKey := routeCacheKey(ctx, format)
 
 		// Try to get from cache
 		cached, err := c.Get(ctx.Request.Context(), cacheKey)
 		if err == nil && cached != "" {
 			// Cache hit
-			ctx.Header("GRSS-Cache-Status", "HIT")
-			ctx.Header("Content-Type", getContentType(format))
-			ctx.String(http.StatusOK, cached)
-			ctx.Abort()
+			writeCachedOutput(ctx, format, cached)
 			return
 		}
 
 		// Cache miss - use singleflight to prevent thundering herd
+		leader := false
 		result, err, _ := sf.Do(cacheKey, func() (interface{}, error) {
+			leader = true
+
 			// Create a custom response writer to capture the response
 			writer := &responseWriter{
 				ResponseWriter: ctx.Writer,
@@ -57,28 +291,110 @@ func Cache(c cache.Cache) gin.HandlerFunc {
 			// Get the response body
 			response := string(writer.body)
 
-			// Cache the response if status is 200
-			if ctx.Writer.Status() == http.StatusOK && response != "" {
-				go func() {
-					bgCtx := context.Background()
-					err := c.Set(bgCtx, cacheKey, response, config.C.Cache.RouteExpire)
-					if err != nil {
-						utils.LogError("Failed to cache response: %v", err)
-					}
-				}()
+			// Cache the response if status is 200, but not stale data that
+			// is being refreshed
+			if ctx.Writer.Status() != http.StatusOK || response == "" || ctx.Writer.Header().Get("GRSS-Cache-Status") == "STALE" {
+				return "", nil
 			}
+			expire := config.C.Cache.OutputExpire
+			go func() {
+				bgCtx := context.Background()
+				err := c.Set(bgCtx, cacheKey, response, expire)
+				if err != nil {
+					utils.LogError("Failed to cache response: %v", err)
+				}
+			}()
 
 			return response, nil
 		})
+		if leader {
+			return
+		}
+
+		// Requests that waited on another one write its response, or run
+		// the chain themselves when it failed
+		if response, _ := result.(string); err == nil && response != "" {
+			writeCachedOutput(ctx, format, response)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// writeCachedOutput responds with rendered output from the cache
+func writeCachedOutput(ctx *gin.Context, format *feed.Format, output string) {
+	ctx.Header("GRSS-Cache-Status", "HIT")
+	ctx.Header("Content-Type", contentType(ctx, format))
+	ctx.Header("Vary", "Accept")
+	setFormatHeaders(ctx, format)
+	ctx.String(http.StatusOK, output)
+	ctx.Abort()
+}
+
+// routeCacheKey returns the canonical cache key of a request: the base URL,
+// the path, the negotiated format and the normalized query parameters that
+// affect the output, sorted by name. Repeated parameters keep their order,
+// which matters for rewrite rules. The self link and proxied image URLs are
+// built from the base URL, so requests to other hosts get their own output.
+func routeCacheKey(ctx *gin.Context, format *feed.Format) string {
+	declared := declaredParams(ctx)
+	query := cacheQuery(ctx, func(name string) func(string) string {
+		if declared[name] {
+			return keepParam
+		}
+		return outputParams[name]
+	})
+
+	keyData := fmt.Sprintf("%s%s?%s:%s", baseURL(ctx), ctx.Request.URL.Path, query, format.Name)
+	return fmt.Sprintf("grss:cache:%x", sha256.Sum256([]byte(keyData)))
+}
+
+// routeDataCacheKey returns the cache key of the feed data of a request: the
+// path and the query parameters the route declares
+func routeDataCacheKey(ctx *gin.Context) string {
+	declared := declaredParams(ctx)
+	query := cacheQuery(ctx, func(name string) func(string) string {
+		if declared[name] {
+			return keepParam
+		}
+		return nil
+	})
 
-		// If we got the result from singleflight, write it
-		if err == nil && result != nil {
-			response := result.(string)
-			if response != "" && ctx.Writer.Status() == http.StatusOK {
-				ctx.Header("GRSS-Cache-Status", "MISS")
+	keyData := fmt.Sprintf("%s?%s", ctx.Request.URL.Path, query)
+	return fmt.Sprintf("grss:route:%x", sha256.Sum256([]byte(keyData)))
+}
+
+// declaredParams returns the QueryParameters of the route of a request
+func declaredParams(ctx *gin.Context) map[string]bool {
+	declared := map[string]bool{}
+	if route, ok := registry.LookupRoute(ctx.FullPath()); ok {
+		for _, param := range route.QueryParameters {
+			declared[param] = true
+		}
+	}
+	return declared
+}
+
+// cacheQuery encodes the query parameters for which normalizer returns a
+// normalization, with their normalized values. Empty values and the access
+// control parameters are left out.
+func cacheQuery(ctx *gin.Context, normalizer func(name string) func(string) string) string {
+	query := url.Values{}
+	for name, values := range ctx.Request.URL.Query() {
+		if authParams[name] {
+			continue
+		}
+		normalize := normalizer(name)
+		if normalize == nil {
+			continue
+		}
+		for _, value := range values {
+			if value = normalize(value); value != "" {
+				query.Add(name, value)
 			}
 		}
 	}
+	return query.Encode()
 }
 
 // responseWriter wraps gin.ResponseWriter to capture response body
@@ -97,14 +413,50 @@ func (w *responseWriter) WriteString(s string) (int, error) {
 	return w.ResponseWriter.WriteString(s)
 }
 
-// getContentType returns the content type for a given format
-func getContentType(format string) string {
-	switch format {
-	case "atom":
-		return "application/atom+xml; charset=utf-8"
-	case "json":
-		return "application/json; charset=utf-8"
-	default:
-		return "application/rss+xml; charset=utf-8"
+// bufferedWriter holds back a response until it is replayed
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status int
+	body   []byte
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.body = append(w.body, data...)
+	return len(data), nil
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.body = append(w.body, s...)
+	return len(s), nil
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return len(w.body)
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.status != 0 || len(w.body) > 0
+}
+
+// replay writes the response held back
+func (w *bufferedWriter) replay() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if len(w.body) > 0 {
+		_, _ = w.ResponseWriter.Write(w.body)
 	}
 }
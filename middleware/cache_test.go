@@ -0,0 +1,409 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/cache"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+	"github.com/jean-jacket/grss/routes/registry"
+)
+
+func TestRouteCacheKey(t *testing.T) {
+	config.C = &config.Config{}
+	registry.RegisterRoute("cachetest", registry.Route{
+		Path:            "/issues/:repo",
+		QueryParameters: []string{"state", "code"},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var key string
+	router.GET("/cachetest/issues/:repo", func(c *gin.Context) {
+		format, _ := negotiateFormat(c)
+		key = routeCacheKey(c, format)
+	})
+
+	keyOf := func(target string, accept string) string {
+		req, _ := http.NewRequest("GET", target, nil)
+		req.Host = "grss.example.com"
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		return key
+	}
+
+	base := keyOf("/cachetest/issues/go?filter=runtime&limit=5", "")
+	same := []string{
+		"/cachetest/issues/go?limit=5&filter=runtime",
+		"/cachetest/issues/go?limit=5&filter=runtime&key=secret&code=abc",
+		"/cachetest/issues/go?limit=5&filter=runtime&utm_source=newsletter",
+		"/cachetest/issues/go?limit=5&filter=runtime&sorted=",
+		"/cachetest/issues/go?limit=5&filter=runtime&format=rss",
+		"/cachetest/issues/go?limit=05&filter=runtime",
+		"/cachetest/issues/go?limit=5&filter=runtime&offset=0&brief=none",
+		"/cachetest/issues/go?limit=5&filter=runtime&filter_case_sensitive=true",
+	}
+	for _, target := range same {
+		if k := keyOf(target, ""); k != base {
+			t.Errorf("Expected %s to share the key of the base request", target)
+		}
+	}
+
+	different := []string{
+		"/cachetest/issues/go?limit=5",
+		"/cachetest/issues/go?limit=5&filter=runtime&state=closed",
+		"/cachetest/issues/go?limit=5&filter=runtime&format=atom",
+		"/cachetest/issues/net?limit=5&filter=runtime",
+		"/cachetest/issues/go?limit=5&filter=runtime&expr=" + "age%20%3C%201d",
+		"/cachetest/issues/go?limit=6&filter=runtime",
+		"/cachetest/issues/go?limit=5&filter=runtime&filter_case_sensitive=false",
+	}
+	for _, target := range different {
+		if k := keyOf(target, ""); k == base {
+			t.Errorf("Expected %s to have its own key", target)
+		}
+	}
+
+	if k := keyOf("/cachetest/issues/go?limit=5&filter=runtime", "application/atom+xml"); k == base {
+		t.Error("Expected the negotiated format to be part of the key")
+	}
+
+	// Absolute URLs in the output depend on the host
+	req, _ := http.NewRequest("GET", "/cachetest/issues/go?filter=runtime&limit=5", nil)
+	req.Host = "attacker.example.net"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if key == base {
+		t.Error("Expected the host to be part of the key")
+	}
+
+	// Boolean values are normalized
+	insensitive := keyOf("/cachetest/issues/go?filter=runtime&filter_case_sensitive=false", "")
+	if k := keyOf("/cachetest/issues/go?filter=runtime&filter_case_sensitive=0", ""); k != insensitive {
+		t.Error("Expected equivalent boolean values to share a key")
+	}
+
+	// Repeated parameters keep their order
+	first := keyOf("/cachetest/issues/go?rewrite=title:s/a/b/&rewrite=title:s/b/c/", "")
+	second := keyOf("/cachetest/issues/go?rewrite=title:s/b/c/&rewrite=title:s/a/b/", "")
+	if first == second {
+		t.Error("Expected the order of rewrite rules to be part of the key")
+	}
+}
+
+func TestCache_QueryParameters(t *testing.T) {
+	config.C = &config.Config{}
+	config.C.Cache.Type = "memory"
+	config.C.Cache.OutputExpire = time.Minute
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Cache(cache.NewMemoryCache(100)))
+	router.Use(Template())
+	router.Use(Parameter(nil))
+	router.GET("/test", func(c *gin.Context) {
+		c.Set(ContextKeyData, &feed.Data{
+			Title: "Test",
+			Link:  "https://example.com",
+			Item: []feed.Item{
+				{Title: "runtime: fix", Link: "https://example.com/1"},
+				{Title: "net/http: add", Link: "https://example.com/2"},
+			},
+		})
+	})
+
+	request := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", target, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Wait for the unfiltered feed to be cached in the background
+	request("/test")
+	cached := false
+	for i := 0; i < 100 && !cached; i++ {
+		time.Sleep(10 * time.Millisecond)
+		cached = request("/test").Header().Get("GRSS-Cache-Status") == "HIT"
+	}
+	if !cached {
+		t.Fatal("Expected the unfiltered feed to be cached")
+	}
+
+	w := request("/test?filter=runtime")
+	if w.Header().Get("GRSS-Cache-Status") == "HIT" {
+		t.Error("Expected a filtered request not to be served the unfiltered feed")
+	}
+	if body := w.Body.String(); strings.Contains(body, "net/http: add") || !strings.Contains(body, "runtime: fix") {
+		t.Errorf("Expected filtered output, got %s", body)
+	}
+}
+
+// newCacheTestRouter returns a router with both cache tiers whose /test
+// handler waits for release and counts its calls
+func newCacheTestRouter(outputExpire time.Duration, release <-chan struct{}, calls *atomic.Int32) *gin.Engine {
+	config.C = &config.Config{}
+	config.C.Cache.Type = "memory"
+	config.C.Cache.RouteExpire = time.Minute
+	config.C.Cache.OutputExpire = outputExpire
+	store := cache.NewMemoryCache(100)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if outputExpire > 0 {
+		router.Use(Cache(store))
+	}
+	router.Use(Template())
+	router.Use(Parameter(nil))
+	router.Use(RouteCache(store))
+	router.GET("/test", func(c *gin.Context) {
+		calls.Add(1)
+		<-release
+		c.Set(ContextKeyData, &feed.Data{
+			Title: "Test",
+			Link:  "https://example.com",
+			Item: []feed.Item{
+				{Title: "runtime: fix", Link: "https://example.com/1"},
+				{Title: "net/http: add", Link: "https://example.com/2"},
+			},
+		})
+	})
+	return router
+}
+
+func TestRouteCache(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	var calls atomic.Int32
+	router := newCacheTestRouter(0, release, &calls)
+
+	request := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", target, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request("/test"); w.Header().Get("GRSS-Cache-Status") != "MISS" {
+		t.Errorf("Expected the first request to miss, got %q", w.Header().Get("GRSS-Cache-Status"))
+	}
+
+	// Wait for the feed data to be cached in the background
+	cached := false
+	for i := 0; i < 100 && !cached; i++ {
+		time.Sleep(10 * time.Millisecond)
+		cached = request("/test").Header().Get("GRSS-Cache-Status") == "HIT"
+	}
+	if !cached {
+		t.Fatal("Expected the feed data to be cached")
+	}
+
+	// Other formats and parameters are served from the cached data
+	w := request("/test?format=atom&filter=runtime")
+	if body := w.Body.String(); !strings.Contains(body, "<feed") || strings.Contains(body, "net/http: add") || !strings.Contains(body, "runtime: fix") {
+		t.Errorf("Expected filtered Atom output, got %s", body)
+	}
+	// The cached data is not modified by the requests it serves
+	if body := request("/test?format=json").Body.String(); !strings.Contains(body, "net/http: add") {
+		t.Errorf("Expected unfiltered output, got %s", body)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected the handler to run once, got %d", n)
+	}
+}
+
+func TestCache_ConcurrentRequests(t *testing.T) {
+	for _, outputExpire := range []time.Duration{0, time.Minute} {
+		release := make(chan struct{})
+		var calls atomic.Int32
+		router := newCacheTestRouter(outputExpire, release, &calls)
+
+		// Requests arriving while the handler runs wait for its result
+		var wg sync.WaitGroup
+		recorders := make([]*httptest.ResponseRecorder, 5)
+		for i := range recorders {
+			recorders[i] = httptest.NewRecorder()
+			wg.Add(1)
+			go func(w *httptest.ResponseRecorder) {
+				defer wg.Done()
+				req, _ := http.NewRequest("GET", "/test", nil)
+				router.ServeHTTP(w, req)
+			}(recorders[i])
+		}
+		for calls.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		for i, w := range recorders {
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "runtime: fix") {
+				t.Errorf("output expire %v, request %d: expected the feed, got %d %s", outputExpire, i, w.Code, w.Body.String())
+			}
+		}
+		if n := calls.Load(); n != 1 {
+			t.Errorf("output expire %v: expected the handler to run once, got %d", outputExpire, n)
+		}
+	}
+}
+
+func TestRouteCache_StaleWhileRevalidate(t *testing.T) {
+	config.C = &config.Config{}
+	config.C.Cache.Type = "memory"
+	config.C.Cache.RouteExpire = 50 * time.Millisecond
+	config.C.Cache.StaleExpire = time.Minute
+
+	var calls atomic.Int32
+	registry.RegisterRoute("swrtest", registry.Route{
+		Path: "/feed",
+		Handler: func(c *gin.Context) (*feed.Data, error) {
+			n := calls.Add(1)
+			return &feed.Data{
+				Title: "Test",
+				Link:  "https://example.com",
+				Item:  []feed.Item{{Title: fmt.Sprintf("version %d", n), Link: "https://example.com/1"}},
+			}, nil
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Template())
+	router.Use(RouteCache(cache.NewMemoryCache(100)))
+	router.GET("/swrtest/feed", func(c *gin.Context) {
+		route, _ := registry.LookupRoute(c.FullPath())
+		data, _ := route.Handler(c)
+		c.Set(ContextKeyData, data)
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/swrtest/feed", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	cached := request()
+	for i := 0; i < 100 && cached.Header().Get("GRSS-Cache-Status") != "HIT"; i++ {
+		time.Sleep(10 * time.Millisecond)
+		cached = request()
+	}
+
+	// Expired data is served right away and refreshed in the background
+	time.Sleep(60 * time.Millisecond)
+	w := request()
+	if w.Header().Get("GRSS-Cache-Status") != "STALE" || w.Body.String() != cached.Body.String() {
+		t.Fatalf("Expected stale data, got %q %s", w.Header().Get("GRSS-Cache-Status"), w.Body.String())
+	}
+	if !strings.HasPrefix(w.Header().Get("Warning"), "110") {
+		t.Errorf("Expected a stale warning, got %q", w.Header().Get("Warning"))
+	}
+
+	refreshed := false
+	for i := 0; i < 100 && !refreshed; i++ {
+		time.Sleep(5 * time.Millisecond)
+		refreshed = request().Body.String() != cached.Body.String()
+	}
+	if !refreshed {
+		t.Error("Expected the data to be refreshed in the background")
+	}
+}
+
+func TestRouteCache_StaleIfError(t *testing.T) {
+	config.C = &config.Config{}
+	config.C.Cache.Type = "memory"
+	config.C.Cache.RouteExpire = 50 * time.Millisecond
+	config.C.Cache.StaleExpire = time.Minute
+
+	// Routes stay registered, so every run has its own namespace
+	namespace := fmt.Sprintf("sietest%d", time.Now().UnixNano())
+	var failing atomic.Bool
+	var calls atomic.Int32
+	registry.RegisterRoute(namespace, registry.Route{
+		Path: "/feed/:id",
+		Handler: func(c *gin.Context) (*feed.Data, error) {
+			calls.Add(1)
+			if failing.Load() {
+				return nil, errors.New("upstream unavailable")
+			}
+			return &feed.Data{
+				Title: "Test",
+				Link:  "https://example.com",
+				Item:  []feed.Item{{Title: "cached item", Link: "https://example.com/1"}},
+			}, nil
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Template())
+	router.Use(RouteCache(cache.NewMemoryCache(100)))
+	// Fails like registry routes do
+	router.GET("/"+namespace+"/feed/:id", func(c *gin.Context) {
+		route, _ := registry.LookupRoute(c.FullPath())
+		data, err := route.Handler(c)
+		if err != nil {
+			_ = c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": gin.H{"message": err.Error()}})
+			c.Abort()
+			return
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	request := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", target, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	request("/" + namespace + "/feed/1")
+	for i := 0; i < 100 && request("/"+namespace+"/feed/1").Header().Get("GRSS-Cache-Status") != "HIT"; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The failing background refresh is reported by the next requests
+	failing.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	w := request("/" + namespace + "/feed/1")
+	for i := 0; i < 100 && !strings.HasPrefix(w.Header().Get("Warning"), "111"); i++ {
+		time.Sleep(5 * time.Millisecond)
+		w = request("/" + namespace + "/feed/1")
+	}
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "cached item") {
+		t.Errorf("Expected the last good data on errors, got %d %s", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(w.Header().Get("Warning"), "111") {
+		t.Errorf("Expected a revalidation warning, got %q", w.Header().Get("Warning"))
+	}
+
+	// The route is not refreshed again during the backoff
+	before := calls.Load()
+	for i := 0; i < 5; i++ {
+		if w := request("/" + namespace + "/feed/1"); !strings.HasPrefix(w.Header().Get("Warning"), "111") {
+			t.Errorf("Expected a revalidation warning, got %q", w.Header().Get("Warning"))
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if after := calls.Load(); after != before {
+		t.Errorf("Expected no refresh during the backoff, got %d", after-before)
+	}
+
+	// Without cached data the error is returned
+	w = request("/" + namespace + "/feed/2")
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "upstream unavailable") {
+		t.Errorf("Expected the route error, got %d %s", w.Code, w.Body.String())
+	}
+}
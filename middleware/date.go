@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jean-jacket/grss/cache"
+	"github.com/jean-jacket/grss/feed"
+	"github.com/jean-jacket/grss/utils"
+)
+
+// firstSeenExpire is how long the first-seen time of an item is kept after
+// the item was last seen
+const firstSeenExpire = 30 * 24 * time.Hour
+
+// firstSeenRefresh is how often the expiration of a first-seen time is pushed
+// back, instead of writing it on every request
+const firstSeenRefresh = 24 * time.Hour
+
+// dateFallbacks lists the date_fallback policies for items without a date:
+// drop them, keep the zero time, or use the time the item was first seen
+var dateFallbacks = map[string]bool{
+	"drop":       true,
+	"zero":       true,
+	"first_seen": true,
+}
+
+// applyDateFallback applies a date_fallback policy to the items without a
+// publication date. First-seen times are stored in c under scope, usually
+// the request path, and kept while the item is seen; without a cache they
+// are the current time.
+func applyDateFallback(ctx context.Context, c cache.Cache, scope string, items []feed.Item, policy string, now time.Time) []feed.Item {
+	switch policy {
+	case "drop":
+		dated := []feed.Item{}
+		for _, item := range items {
+			if !item.PubDate.IsZero() {
+				dated = append(dated, item)
+			}
+		}
+		return dated
+
+	case "first_seen":
+		for i := range items {
+			if items[i].PubDate.IsZero() {
+				items[i].PubDate = firstSeen(ctx, c, scope, &items[i], now)
+			}
+		}
+	}
+
+	return items
+}
+
+// firstSeen returns the time an item was first seen, recording now when it
+// is new. Items are identified by GUID, link or title. The cached value is
+// the first-seen time and the time its expiration was last pushed back,
+// which happens once per firstSeenRefresh.
+func firstSeen(ctx context.Context, c cache.Cache, scope string, item *feed.Item, now time.Time) time.Time {
+	id := item.GUID
+	if id == "" {
+		id = item.Link
+	}
+	if id == "" {
+		id = item.Title
+	}
+	if c == nil || id == "" {
+		return now
+	}
+
+	key := fmt.Sprintf("grss:firstseen:%x", sha256.Sum256([]byte(scope+"\n"+id)))
+	seen := now
+	var refreshed time.Time
+	if cached, err := c.Get(ctx, key); err == nil && cached != "" {
+		first, last, _ := strings.Cut(cached, " ")
+		if t, err := time.Parse(time.RFC3339Nano, first); err == nil {
+			seen = t
+			refreshed, _ = time.Parse(time.RFC3339Nano, last)
+		}
+	}
+
+	if now.Sub(refreshed) >= firstSeenRefresh {
+		value := seen.UTC().Format(time.RFC3339Nano) + " " + now.UTC().Format(time.RFC3339Nano)
+		if err := c.Set(ctx, key, value, firstSeenExpire); err != nil {
+			utils.LogError("Failed to store first-seen time: %v", err)
+		}
+	}
+	return seen
+}
+
+// localizeDates converts the dates of a feed to loc, which sets the timezone
+// they are rendered in. The dates of all-day events are calendar days in the
+// zone of the source and are kept as they are.
+func localizeDates(data *feed.Data, loc *time.Location) {
+	localize := func(t *time.Time) {
+		if !t.IsZero() {
+			*t = t.In(loc)
+		}
+	}
+
+	localize(&data.PubDate)
+	localize(&data.LastBuildDate)
+	for i := range data.Item {
+		if event := data.Item[i].Event; event == nil || !event.AllDay {
+			localize(&data.Item[i].PubDate)
+		}
+		localize(&data.Item[i].Updated)
+	}
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/cache"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+)
+
+func TestParameter_DateFallback(t *testing.T) {
+	config.C = &config.Config{}
+	dated := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Parameter(cache.NewMemoryCache(100)))
+	var data *feed.Data
+	router.GET("/test", func(c *gin.Context) {
+		data = &feed.Data{
+			Title: "Test",
+			Item: []feed.Item{
+				{Title: "Dated", Link: "https://example.com/1", PubDate: dated},
+				{Title: "Undated", Link: "https://example.com/2"},
+			},
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	request := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test?"+query, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	request("date_fallback=zero")
+	if len(data.Item) != 2 || !data.Item[1].PubDate.IsZero() {
+		t.Errorf("Expected the undated item to keep the zero time, got %+v", data.Item)
+	}
+
+	request("date_fallback=drop")
+	if len(data.Item) != 1 || data.Item[0].Title != "Dated" {
+		t.Errorf("Expected only the dated item, got %+v", data.Item)
+	}
+
+	// The first-seen time is kept across refreshes
+	request("date_fallback=first_seen")
+	first := data.Item[1].PubDate
+	if first.IsZero() || time.Since(first) > time.Minute {
+		t.Fatalf("Expected the undated item to be dated now, got %v", first)
+	}
+	if !data.Item[0].PubDate.Equal(dated) {
+		t.Errorf("Dated items should keep their date, got %v", data.Item[0].PubDate)
+	}
+	time.Sleep(10 * time.Millisecond)
+	request("date_fallback=first_seen")
+	if !data.Item[1].PubDate.Equal(first) {
+		t.Errorf("Expected first-seen time %v on refresh, got %v", first, data.Item[1].PubDate)
+	}
+
+	if w := request("date_fallback=now"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown fallback, got %d", w.Code)
+	}
+}
+
+// countingCache counts the writes to a cache
+type countingCache struct {
+	cache.Cache
+	sets int
+}
+
+func (c *countingCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	c.sets++
+	return c.Cache.Set(ctx, key, value, ttl)
+}
+
+func TestFirstSeen_Writes(t *testing.T) {
+	c := &countingCache{Cache: cache.NewMemoryCache(10)}
+	ctx := context.Background()
+	item := &feed.Item{Link: "https://example.com/1"}
+	now := time.Now()
+
+	first := firstSeen(ctx, c, "/test", item, now)
+	if seen := firstSeen(ctx, c, "/test", item, now.Add(time.Hour)); !seen.Equal(first) || c.sets != 1 {
+		t.Errorf("Expected the first-seen time to be written once, got %v after %d writes", seen, c.sets)
+	}
+
+	// The expiration is pushed back once a day
+	if seen := firstSeen(ctx, c, "/test", item, now.Add(25*time.Hour)); !seen.Equal(first) || c.sets != 2 {
+		t.Errorf("Expected the first-seen time to be refreshed, got %v after %d writes", seen, c.sets)
+	}
+}
+
+func TestParameter_Timezone(t *testing.T) {
+	config.C = &config.Config{}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Template())
+	router.Use(Parameter(nil))
+	router.GET("/test", func(c *gin.Context) {
+		c.Set(ContextKeyData, &feed.Data{
+			Title: "Test",
+			Link:  "https://example.com",
+			Item: []feed.Item{
+				{Title: "Item", Link: "https://example.com/1", PubDate: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)},
+			},
+		})
+	})
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"", "<pubDate>Sat, 01 Mar 2025 12:00:00 +0000</pubDate>"},
+		{"tz=Asia/Tokyo", "<pubDate>Sat, 01 Mar 2025 21:00:00 +0900</pubDate>"},
+		{"tz=America/New_York&format=atom", "<published>2025-03-01T07:00:00-05:00</published>"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test?"+tt.query, nil)
+		router.ServeHTTP(w, req)
+		if !strings.Contains(w.Body.String(), tt.expected) {
+			t.Errorf("%s: expected %s in output:\n%s", tt.query, tt.expected, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test?tz=Mars/Olympus", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown timezone, got %d", w.Code)
+	}
+}
+
+func TestParameter_AllDayEventsKeepTheirDay(t *testing.T) {
+	config.C = &config.Config{}
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Template())
+	router.Use(Parameter(nil))
+	router.GET("/test", func(c *gin.Context) {
+		c.Set(ContextKeyData, &feed.Data{
+			Title: "Holidays",
+			Item: []feed.Item{
+				{Title: "Holiday", GUID: "holiday", PubDate: time.Date(2024, 3, 1, 0, 0, 0, 0, tokyo), Event: &feed.Event{AllDay: true}},
+			},
+		})
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test?format=ics", nil)
+	router.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "DTSTART;VALUE=DATE:20240301") {
+		t.Errorf("Expected the all-day event on Mar 1, got:\n%s", w.Body.String())
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jean-jacket/grss/feed"
+)
+
+// digestPeriods lists the periods of mode=digest
+var digestPeriods = map[string]bool{
+	"day":  true,
+	"week": true,
+}
+
+// digestBucket is the items published in one period
+type digestBucket struct {
+	start time.Time
+	items []feed.Item
+}
+
+// digestItems groups items into one digest item per period, newest period
+// first. Periods start at midnight in loc, weeks on Monday. Items without a
+// date are left out. GUIDs are derived from scope, usually the request path,
+// and the period, so they stay the same as a period fills up.
+func digestItems(data *feed.Data, items []feed.Item, period string, loc *time.Location, scope string) []feed.Item {
+	buckets := map[time.Time]*digestBucket{}
+	for _, item := range items {
+		if item.PubDate.IsZero() {
+			continue
+		}
+		start := periodStart(item.PubDate, period, loc)
+		bucket, ok := buckets[start]
+		if !ok {
+			bucket = &digestBucket{start: start}
+			buckets[start] = bucket
+		}
+		bucket.items = append(bucket.items, item)
+	}
+
+	sorted := make([]*digestBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		sorted = append(sorted, bucket)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].start.After(sorted[j].start)
+	})
+
+	digests := make([]feed.Item, 0, len(sorted))
+	for _, bucket := range sorted {
+		digests = append(digests, newDigestItem(data, bucket, period, scope))
+	}
+	return digests
+}
+
+// periodStart returns the start of the period containing t
+func periodStart(t time.Time, period string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	if period == "week" {
+		// Weekday counts from Sunday, weeks start on Monday
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+	}
+	return start
+}
+
+// newDigestItem synthesizes the digest item of a bucket, listing the titles
+// and links of its items newest first
+func newDigestItem(data *feed.Data, bucket *digestBucket, period string, scope string) feed.Item {
+	items := bucket.items
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].PubDate.After(items[j].PubDate)
+	})
+
+	end := bucket.start.AddDate(0, 0, 1)
+	label := bucket.start.Format("Jan 2, 2006")
+	if period == "week" {
+		end = bucket.start.AddDate(0, 0, 7)
+		label = "the week of " + label
+	}
+
+	count := fmt.Sprintf("%d items", len(items))
+	if len(items) == 1 {
+		count = "1 item"
+	}
+
+	var content, text strings.Builder
+	content.WriteString("<ul>\n")
+	for _, item := range items {
+		title := item.Title
+		if title == "" {
+			title = item.Link
+		}
+		if item.Link != "" {
+			fmt.Fprintf(&content, "<li><a href=\"%s\">%s</a></li>\n", html.EscapeString(item.Link), html.EscapeString(title))
+			fmt.Fprintf(&text, "- %s: %s\n", title, item.Link)
+		} else {
+			fmt.Fprintf(&content, "<li>%s</li>\n", html.EscapeString(title))
+			fmt.Fprintf(&text, "- %s\n", title)
+		}
+	}
+	content.WriteString("</ul>")
+
+	key := scope + "\n" + period + "\n" + bucket.start.Format(time.RFC3339)
+	return feed.Item{
+		Title:       fmt.Sprintf("%s: %s for %s", data.Title, count, label),
+		Link:        data.Link,
+		GUID:        fmt.Sprintf("digest-%x", sha256.Sum256([]byte(key))),
+		PubDate:     end,
+		ContentHTML: content.String(),
+		ContentText: strings.TrimSuffix(text.String(), "\n"),
+	}
+}
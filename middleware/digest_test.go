@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+)
+
+func TestPeriodStart(t *testing.T) {
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+
+	tests := []struct {
+		input    time.Time
+		period   string
+		loc      *time.Location
+		expected time.Time
+	}{
+		{time.Date(2025, 3, 5, 18, 30, 0, 0, time.UTC), "day", time.UTC, time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)},
+		// 18:30 UTC is already the next day in Tokyo
+		{time.Date(2025, 3, 5, 18, 30, 0, 0, time.UTC), "day", tokyo, time.Date(2025, 3, 6, 0, 0, 0, 0, tokyo)},
+		// Wednesday and Sunday belong to the week starting on Monday
+		{time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC), "week", time.UTC, time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC), "week", time.UTC, time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), "week", time.UTC, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if result := periodStart(tt.input, tt.period, tt.loc); !result.Equal(tt.expected) {
+			t.Errorf("periodStart(%v, %s) = %v, expected %v", tt.input, tt.period, result, tt.expected)
+		}
+	}
+}
+
+func TestParameter_Digest(t *testing.T) {
+	config.C = &config.Config{}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Parameter(nil))
+	var data *feed.Data
+	router.GET("/test", func(c *gin.Context) {
+		data = &feed.Data{
+			Title: "Issues",
+			Link:  "https://example.com",
+			Item: []feed.Item{
+				{Title: "Bug <1>", Link: "https://example.com/1", PubDate: time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)},
+				{Title: "Bug 2", Link: "https://example.com/2", PubDate: time.Date(2025, 3, 5, 17, 0, 0, 0, time.UTC)},
+				{Title: "Feature 3", Link: "https://example.com/3", PubDate: time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)},
+				{Title: "Undated", Link: "https://example.com/4"},
+			},
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	request := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test?"+query, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	request("mode=digest&period=day&filter_title=Bug|Feature")
+	if len(data.Item) != 2 {
+		t.Fatalf("Expected 2 daily digests, got %d", len(data.Item))
+	}
+	digest := data.Item[0]
+	if digest.Title != "Issues: 2 items for Mar 5, 2025" {
+		t.Errorf("Unexpected digest title: %q", digest.Title)
+	}
+	if !digest.PubDate.Equal(time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the digest dated at the end of the day, got %v", digest.PubDate)
+	}
+	if !strings.Contains(digest.ContentHTML, `<li><a href="https://example.com/2">Bug 2</a></li>`) ||
+		!strings.Contains(digest.ContentHTML, `Bug &lt;1&gt;`) {
+		t.Errorf("Expected the titles and links in the digest, got %q", digest.ContentHTML)
+	}
+	if strings.Index(digest.ContentHTML, "Bug 2") > strings.Index(digest.ContentHTML, "Bug &lt;1&gt;") {
+		t.Error("Expected the newest item first in the digest")
+	}
+	if data.Item[1].Title != "Issues: 1 item for Mar 4, 2025" {
+		t.Errorf("Unexpected digest title: %q", data.Item[1].Title)
+	}
+
+	// GUIDs are stable per bucket, even when the bucket content changes
+	guid := digest.GUID
+	request("mode=digest&filter_title=Bug")
+	if data.Item[0].GUID != guid {
+		t.Errorf("Expected the same GUID for the same day, got %q and %q", guid, data.Item[0].GUID)
+	}
+
+	request("mode=digest&period=week")
+	if len(data.Item) != 1 || data.Item[0].Title != "Issues: 3 items for the week of Mar 3, 2025" {
+		t.Errorf("Expected one weekly digest, got %+v", data.Item)
+	}
+	if data.Item[0].GUID == guid {
+		t.Error("Expected weekly digests to have their own GUID")
+	}
+
+	if w := request("mode=digest&period=month"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown period, got %d", w.Code)
+	}
+}
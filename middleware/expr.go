@@ -0,0 +1,438 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jean-jacket/grss/feed"
+)
+
+// The expr query parameter filters items with a small boolean language:
+//
+//	category == "bug" && !(title ~ "wip") && age < 7d
+//
+// String fields (title, description, author, category, link) are compared
+// with ==, != and the regex operators ~ and !~. Fields with several values,
+// such as category, match when any value does. The age of an item is
+// compared with <, <=, > and >= against durations in s, m, h, d or w.
+// Conditions are combined with !, && and || and grouped with parentheses.
+
+// exprNode is a node of a parsed filter expression
+type exprNode interface {
+	eval(env *exprEnv) (bool, error)
+}
+
+// exprEnv is the item an expression is evaluated against
+type exprEnv struct {
+	item *feed.Item
+	now  time.Time
+}
+
+// exprFields returns the values of the string fields of an item
+var exprFields = map[string]func(item *feed.Item) []string{
+	"title": func(item *feed.Item) []string {
+		return []string{item.Title}
+	},
+	"description": func(item *feed.Item) []string {
+		return []string{itemContent(item)}
+	},
+	"author": func(item *feed.Item) []string {
+		var values []string
+		for _, author := range item.AllAuthors() {
+			if author.Name != "" {
+				values = append(values, author.Name)
+			}
+			if author.Email != "" {
+				values = append(values, author.Email)
+			}
+		}
+		return values
+	},
+	"category": func(item *feed.Item) []string {
+		return item.Category
+	},
+	"link": func(item *feed.Item) []string {
+		return []string{item.Link}
+	},
+}
+
+const (
+	// maxExprLength bounds the length in bytes of an expression
+	maxExprLength = 4096
+
+	// maxExprDepth bounds the nesting of negations and parentheses
+	maxExprDepth = 32
+)
+
+// parseExpr parses a filter expression. Regex operands are compiled with
+// compile, string equality honors caseSensitive.
+func parseExpr(src string, compile func(pattern string) (matcher, error), caseSensitive bool) (exprNode, error) {
+	if len(src) > maxExprLength {
+		return nil, fmt.Errorf("expression longer than %d bytes", maxExprLength)
+	}
+
+	tokens, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens, compile: compile, caseSensitive: caseSensitive}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return node, nil
+}
+
+// Token kinds of the expression language
+const (
+	tokenEOF = iota
+	tokenIdent
+	tokenString
+	tokenDuration
+	tokenOperator
+)
+
+// exprToken is a lexical token with its position in the source
+type exprToken struct {
+	kind int
+	text string
+	pos  int
+}
+
+// exprOperators lists the operators, longest first
+var exprOperators = []string{"&&", "||", "==", "!=", "!~", "<=", ">=", "~", "<", ">", "!", "(", ")"}
+
+// lexExpr splits an expression into tokens
+func lexExpr(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '"':
+			// String literal with backslash escapes
+			var builder strings.Builder
+			start := i
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				builder.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, exprToken{kind: tokenString, text: builder.String(), pos: start})
+
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokenDuration, text: string(runes[start:i]), pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+
+		default:
+			matched := false
+			for _, op := range exprOperators {
+				if hasOperatorPrefix(runes[i:], op) {
+					tokens = append(tokens, exprToken{kind: tokenOperator, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected %q at position %d", r, i)
+			}
+		}
+	}
+
+	return append(tokens, exprToken{kind: tokenEOF, text: "end of expression", pos: len(runes)}), nil
+}
+
+// hasOperatorPrefix reports whether runes start with the ASCII operator op
+func hasOperatorPrefix(runes []rune, op string) bool {
+	if len(runes) < len(op) {
+		return false
+	}
+	for j := 0; j < len(op); j++ {
+		if runes[j] != rune(op[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+// exprParser is a recursive descent parser over the tokens of an expression
+type exprParser struct {
+	tokens        []exprToken
+	pos           int
+	depth         int // Nesting of the unary being parsed
+	compile       func(pattern string) (matcher, error)
+	caseSensitive bool
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is the operator op
+func (p *exprParser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == tokenOperator && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// parseOr parses: and ("||" and)*
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses: unary ("&&" unary)*
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseUnary parses: "!" unary | "(" or ")" | comparison
+func (p *exprParser) parseUnary() (exprNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxExprDepth {
+		tok := p.peek()
+		return nil, fmt.Errorf("expression nested deeper than %d at position %d", maxExprDepth, tok.pos)
+	}
+
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+
+	if p.accept("(") {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			tok := p.peek()
+			return nil, fmt.Errorf("expected \")\" at position %d, found %q", tok.pos, tok.text)
+		}
+		return node, nil
+	}
+
+	return p.parseComparison()
+}
+
+// parseComparison parses: field operator value
+func (p *exprParser) parseComparison() (exprNode, error) {
+	field := p.next()
+	if field.kind != tokenIdent {
+		return nil, fmt.Errorf("expected a field at position %d, found %q", field.pos, field.text)
+	}
+	op := p.next()
+	if op.kind != tokenOperator {
+		return nil, fmt.Errorf("expected an operator at position %d, found %q", op.pos, op.text)
+	}
+	value := p.next()
+
+	if field.text == "age" {
+		switch op.text {
+		case "<", "<=", ">", ">=":
+		default:
+			return nil, fmt.Errorf("operator %q at position %d does not apply to age", op.text, op.pos)
+		}
+		if value.kind != tokenDuration {
+			return nil, fmt.Errorf("expected a duration at position %d, found %q", value.pos, value.text)
+		}
+		duration, err := parseExprDuration(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q at position %d", value.text, value.pos)
+		}
+		return &ageNode{op: op.text, value: duration}, nil
+	}
+
+	values, ok := exprFields[field.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q at position %d", field.text, field.pos)
+	}
+	if value.kind != tokenString {
+		return nil, fmt.Errorf("expected a string at position %d, found %q", value.pos, value.text)
+	}
+
+	node := &stringNode{values: values, op: op.text, value: value.text, caseSensitive: p.caseSensitive}
+	switch op.text {
+	case "==", "!=":
+	case "~", "!~":
+		m, err := p.compile(value.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern at position %d: %v", value.pos, err)
+		}
+		node.match = m
+	default:
+		return nil, fmt.Errorf("operator %q at position %d does not apply to %s", op.text, op.pos, field.text)
+	}
+	return node, nil
+}
+
+// parseExprDuration parses a duration such as 90s, 30m, 12h, 7d or 2w
+func parseExprDuration(s string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"s": time.Second,
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+
+	i := strings.IndexFunc(s, unicode.IsLetter)
+	if i <= 0 {
+		return 0, fmt.Errorf("missing unit")
+	}
+	unit, ok := units[s[i:]]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", s[i:])
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// logicalNode combines two conditions with && or ||
+type logicalNode struct {
+	and         bool
+	left, right exprNode
+}
+
+func (n *logicalNode) eval(env *exprEnv) (bool, error) {
+	left, err := n.left.eval(env)
+	if err != nil || left != n.and {
+		return left, err
+	}
+	return n.right.eval(env)
+}
+
+// notNode negates a condition
+type notNode struct {
+	operand exprNode
+}
+
+func (n *notNode) eval(env *exprEnv) (bool, error) {
+	result, err := n.operand.eval(env)
+	return !result, err
+}
+
+// stringNode compares a string field. Positive operators match when any
+// value does, negated operators when none does.
+type stringNode struct {
+	values        func(item *feed.Item) []string
+	op            string
+	value         string
+	match         matcher
+	caseSensitive bool
+}
+
+func (n *stringNode) eval(env *exprEnv) (bool, error) {
+	found := false
+	for _, v := range n.values(env.item) {
+		var matched bool
+		switch n.op {
+		case "==", "!=":
+			matched = v == n.value || (!n.caseSensitive && strings.EqualFold(v, n.value))
+		default:
+			var err error
+			if matched, err = n.match.MatchString(v); err != nil {
+				return false, err
+			}
+		}
+		if matched {
+			found = true
+			break
+		}
+	}
+
+	if n.op == "!=" || n.op == "!~" {
+		return !found, nil
+	}
+	return found, nil
+}
+
+// ageNode compares the time since the item was published. Items without a
+// date have no age and never match.
+type ageNode struct {
+	op    string
+	value time.Duration
+}
+
+func (n *ageNode) eval(env *exprEnv) (bool, error) {
+	if env.item.PubDate.IsZero() {
+		return false, nil
+	}
+
+	age := env.now.Sub(env.item.PubDate)
+	switch n.op {
+	case "<":
+		return age < n.value, nil
+	case "<=":
+		return age <= n.value, nil
+	case ">":
+		return age > n.value, nil
+	default:
+		return age >= n.value, nil
+	}
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+)
+
+func TestParseExpr(t *testing.T) {
+	now := time.Now()
+	item := &feed.Item{
+		Title:    "WIP: Fix crash",
+		Author:   "Alice",
+		Category: []string{"bug", "ui"},
+		Link:     "https://example.com/issues/1",
+		PubDate:  now.Add(-48 * time.Hour),
+	}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`category == "bug"`, true},
+		{`category != "bug"`, false},
+		{`category == "feature"`, false},
+		{`title ~ "^WIP"`, true},
+		{`title !~ "^WIP"`, false},
+		{`category == "bug" && !(title ~ "wip") && age < 7d`, true},
+		{`category == "bug" && title ~ "wip"`, false},
+		{`age < 1d || author == "Alice"`, true},
+		{`age >= 2d && age < 1w`, true},
+		{`age > 3d`, false},
+		{`link ~ "example\\.com" && description == ""`, true},
+		{`!(category == "ui" || category == "docs")`, false},
+	}
+	for _, tt := range tests {
+		node, err := parseExpr(tt.expr, exprCompiler(), true)
+		if err != nil {
+			t.Fatalf("parseExpr(%q) failed: %v", tt.expr, err)
+		}
+		result, err := node.eval(&exprEnv{item: item, now: now})
+		if err != nil {
+			t.Fatalf("eval(%q) failed: %v", tt.expr, err)
+		}
+		if result != tt.expected {
+			t.Errorf("%s = %v, expected %v", tt.expr, result, tt.expected)
+		}
+	}
+
+	// Nesting within the limit is accepted
+	if _, err := parseExpr(strings.Repeat("!(", 10)+`title == "x"`+strings.Repeat(")", 10), exprCompiler(), true); err != nil {
+		t.Errorf("Expected nested expression to parse, got %v", err)
+	}
+
+	// Without case sensitivity, equality ignores case
+	node, _ := parseExpr(`category == "BUG"`, exprCompiler(), false)
+	if result, _ := node.eval(&exprEnv{item: item, now: now}); !result {
+		t.Error("Expected case-insensitive equality to match")
+	}
+}
+
+func TestParseExpr_Errors(t *testing.T) {
+	tests := []string{
+		`title`,
+		`title ==`,
+		`title == "unterminated`,
+		`unknown == "x"`,
+		`title < "x"`,
+		`age == 7d`,
+		`age < "7d"`,
+		`age < 7y`,
+		`(title == "x"`,
+		`title == "x" extra`,
+		`title ~ "("`,
+		`title @ "x"`,
+		strings.Repeat(`title == "x" || `, 300) + `title == "x"`,
+		strings.Repeat("(", 100) + `title == "x"` + strings.Repeat(")", 100),
+		strings.Repeat("!", 100) + `title == "x"`,
+	}
+	for _, src := range tests {
+		if _, err := parseExpr(src, exprCompiler(), true); err == nil {
+			t.Errorf("Expected error for %q", src)
+		}
+	}
+}
+
+func TestParameter_FieldFilters(t *testing.T) {
+	config.C = &config.Config{}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Parameter(nil))
+	var data *feed.Data
+	router.GET("/test", func(c *gin.Context) {
+		data = &feed.Data{
+			Title: "Test",
+			Item: []feed.Item{
+				{Title: "Crash on start", Author: "Alice", Category: []string{"Bug"}},
+				{Title: "Dark mode", Author: "Bob", Category: []string{"Feature"}},
+				{Title: "Typo in docs", Authors: []feed.Person{{Name: "Bot", Email: "bot@example.com"}}, Category: []string{"Bug", "Docs"}},
+			},
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"filter_category=Bug", []string{"Crash on start", "Typo in docs"}},
+		{"filter_category=bug", []string{}},
+		{"filter_category=bug&filter_case_sensitive=false", []string{"Crash on start", "Typo in docs"}},
+		{"filterout_category=Docs", []string{"Crash on start", "Dark mode"}},
+		{"filter_author=example%5C.com", []string{"Typo in docs"}},
+		{"filterout_author=%5EB", []string{"Crash on start"}},
+		{"filterout_title=Crash&filter_category=Bug", []string{"Typo in docs"}},
+		{"expr=" + url.QueryEscape(`category == "Bug" && !(author ~ "^Bot")`), []string{"Crash on start"}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test?"+tt.query, nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d: %s", tt.query, w.Code, w.Body.String())
+			continue
+		}
+		var titles []string
+		for _, item := range data.Item {
+			titles = append(titles, item.Title)
+		}
+		if len(titles) != len(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.query, tt.expected, titles)
+			continue
+		}
+		for i := range titles {
+			if titles[i] != tt.expected[i] {
+				t.Errorf("%s: expected %v, got %v", tt.query, tt.expected, titles)
+				break
+			}
+		}
+	}
+
+	// Invalid expressions are rejected before the handler runs
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test?expr="+url.QueryEscape(`age < 7`), nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid expr, got %d", w.Code)
+	}
+}
+
+// exprCompiler returns an RE2 pattern compiler for parseExpr
+func exprCompiler() func(pattern string) (matcher, error) {
+	budget := newMatchBudget(filterMatchBudget)
+	return func(pattern string) (matcher, error) {
+		return compileMatcher("re2", pattern, true, budget)
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/feed"
+)
+
+const (
+	// ContextKeyFormat is the key for storing the negotiated output format in context
+	ContextKeyFormat = "feed_format"
+)
+
+// negotiateFormat determines the output format of the request from the format
+// query parameter, falling back to the Accept header and the default format.
+// Path suffixes are turned into a format query parameter by FormatExtension.
+func negotiateFormat(c *gin.Context) (*feed.Format, error) {
+	if cached, exists := c.Get(ContextKeyFormat); exists {
+		return cached.(*feed.Format), nil
+	}
+
+	var format *feed.Format
+	if name := c.Query(paramFormat); name != "" {
+		found, ok := feed.LookupFormat(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown format: %s", name)
+		}
+		format = found
+	} else if found, ok := acceptedFormat(c.GetHeader("Accept")); ok {
+		format = found
+	} else {
+		format, _ = feed.LookupFormat(feed.DefaultFormat)
+	}
+
+	c.Set(ContextKeyFormat, format)
+	return format, nil
+}
+
+// acceptedFormat returns the preferred registered format listed in an Accept
+// header. Wildcards are ignored so that generic clients get the default format.
+func acceptedFormat(accept string) (*feed.Format, bool) {
+	type acceptRange struct {
+		mediaType string
+		quality   float64
+	}
+
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || strings.HasSuffix(mediaType, "/*") {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	for _, r := range ranges {
+		if format, ok := feed.LookupFormatByMediaType(r.mediaType); ok && !format.Explicit {
+			return format, true
+		}
+	}
+	return nil, false
+}
+
+// acceptsHTML reports whether an Accept header comes from a browser
+func acceptsHTML(accept string) bool {
+	return strings.Contains(accept, "text/html")
+}
+
+// FormatExtension wraps the router so that a registered format extension on
+// the request path selects the output format, e.g. /github/issue/golang/go.atom
+// is served as /github/issue/golang/go?format=atom. An explicit format query
+// parameter takes precedence over the extension.
+func FormatExtension(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ext := strings.TrimPrefix(path.Ext(r.URL.Path), ".")
+		if format, ok := feed.LookupFormatByExtension(ext); ok && !bypassPaths[r.URL.Path] {
+			r.URL.Path = strings.TrimSuffix(r.URL.Path, "."+ext)
+			r.URL.RawPath = ""
+
+			query := r.URL.Query()
+			if query.Get(paramFormat) == "" {
+				query.Set(paramFormat, format.Name)
+				r.URL.RawQuery = query.Encode()
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
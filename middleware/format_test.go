@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+)
+
+func newFormatTestRouter() *gin.Engine {
+	config.C = &config.Config{}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Template())
+	router.GET("/test/:name", func(c *gin.Context) {
+		c.Set(ContextKeyData, &feed.Data{
+			Title: "Test " + c.Param("name"),
+			Link:  "https://example.com",
+		})
+	})
+	return router
+}
+
+func TestTemplate_FormatNegotiation(t *testing.T) {
+	handler := FormatExtension(newFormatTestRouter())
+
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		contentType string
+	}{
+		{"default", "/test/feed", "", "application/rss+xml"},
+		{"query", "/test/feed?format=atom", "", "application/atom+xml"},
+		{"accept", "/test/feed", "application/feed+json", "application/json"},
+		{"accept quality", "/test/feed", "application/rss+xml;q=0.5, application/atom+xml", "application/atom+xml"},
+		{"accept wildcard", "/test/feed", "text/html, */*;q=0.8", "application/rss+xml"},
+		{"explicit format", "/test/feed.html", "", "text/html"},
+		{"extension", "/test/feed.atom", "", "application/atom+xml"},
+		{"query over extension", "/test/feed.atom?format=json", "", "application/json"},
+		{"query over accept", "/test/feed?format=rss", "application/atom+xml", "application/rss+xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) {
+				t.Errorf("Expected content type %s, got %s", tt.contentType, w.Header().Get("Content-Type"))
+			}
+			if !strings.Contains(w.Body.String(), "Test feed") {
+				t.Errorf("Expected extension to be stripped from the route parameter: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTemplate_UnknownFormat(t *testing.T) {
+	called := false
+	router := newFormatTestRouter()
+	router.GET("/called", func(c *gin.Context) {
+		called = true
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/called?format=yaml", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown format, got %d", w.Code)
+	}
+	if called {
+		t.Error("Handler should not run for unknown formats")
+	}
+}
+
+func TestTemplate_Stylesheet(t *testing.T) {
+	router := newFormatTestRouter()
+	config.C.FeedStylesheet = true
+
+	// Browsers get generic XML so that the stylesheet is applied
+	req := httptest.NewRequest(http.MethodGet, "/test/feed", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if !strings.Contains(w.Body.String(), `<?xml-stylesheet type="text/xsl" href="/feed.xsl"?>`) {
+		t.Error("Expected xml-stylesheet processing instruction")
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/xml") {
+		t.Errorf("Expected application/xml for browsers, got %s", w.Header().Get("Content-Type"))
+	}
+	if csp := w.Header().Get("Content-Security-Policy"); csp != feed.StylesheetContentSecurityPolicy {
+		t.Errorf("Expected the stylesheet Content-Security-Policy, got %q", csp)
+	}
+
+	// Feed readers keep the feed media type
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/feed", nil))
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/rss+xml") {
+		t.Errorf("Expected application/rss+xml for feed readers, got %s", w.Header().Get("Content-Type"))
+	}
+
+	// Formats without stylesheet support are unaffected
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/feed?format=json", nil))
+	if strings.Contains(w.Body.String(), "xml-stylesheet") {
+		t.Error("Expected no stylesheet in JSON output")
+	}
+}
+
+func TestTemplate_PreviewSecurityPolicy(t *testing.T) {
+	router := newFormatTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/feed?format=html", nil))
+	policy := w.Header().Get("Content-Security-Policy")
+	for _, directive := range []string{"default-src 'none'", "base-uri 'none'", "form-action 'none'", "frame-ancestors 'none'"} {
+		if !strings.Contains(policy, directive) {
+			t.Errorf("Expected %q in the preview Content-Security-Policy, got %q", directive, policy)
+		}
+	}
+
+	// Feeds are not pages
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/feed", nil))
+	if policy := w.Header().Get("Content-Security-Policy"); policy != "" {
+		t.Errorf("Expected no Content-Security-Policy for RSS, got %q", policy)
+	}
+}
+
+func TestTemplate_ExportFormatsFiltered(t *testing.T) {
+	config.C = &config.Config{}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Template())
+	router.Use(Parameter(nil))
+	router.GET("/test", func(c *gin.Context) {
+		c.Set(ContextKeyData, &feed.Data{
+			Title: "Test",
+			Item: []feed.Item{
+				{Title: "Keep one", Link: "https://example.com/1"},
+				{Title: "Drop", Link: "https://example.com/2"},
+				{Title: "Keep two", Link: "https://example.com/3"},
+			},
+		})
+	})
+
+	for _, format := range []string{"csv", "ndjson"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test?format="+format+"&filter=Keep&limit=1", nil))
+
+		body := w.Body.String()
+		if !strings.Contains(body, "Keep one") || strings.Contains(body, "Drop") || strings.Contains(body, "Keep two") {
+			t.Errorf("%s: expected filtered and limited items, got %s", format, body)
+		}
+	}
+}
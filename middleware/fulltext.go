@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/cache"
+	"github.com/jean-jacket/grss/client"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/extract"
+	"github.com/jean-jacket/grss/feed"
+	"github.com/jean-jacket/grss/utils"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// fullTextConcurrency bounds the number of pages fetched at once per
+	// request
+	fullTextConcurrency = 5
+
+	// fullTextMaxItems bounds the number of items extracted per request
+	fullTextMaxItems = 50
+)
+
+var fullTextSF singleflight.Group
+
+// FullText middleware replaces the content of items with the main content of
+// their linked pages when mode=fulltext is requested. Only the items that
+// offset and limit can return are extracted, and at most fullTextMaxItems;
+// the others keep their content. Extracted pages are cached per URL for
+// CACHE_CONTENT_EXPIRE; c may be nil to disable caching.
+func FullText(c cache.Cache) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if ctx.Query(paramMode) != "fulltext" {
+			return
+		}
+
+		dataInterface, exists := ctx.Get(ContextKeyData)
+		if !exists {
+			return
+		}
+		data, ok := dataInterface.(*feed.Data)
+		if !ok {
+			return
+		}
+
+		items := data.Item
+		if n := fullTextItems(ctx); n < len(items) {
+			items = items[:n]
+		}
+		fetchFullText(ctx.Request.Context(), c, items)
+	}
+}
+
+// fullTextItems returns the number of items to extract: the items up to
+// offset plus limit, when a limit is requested, and at most fullTextMaxItems
+func fullTextItems(ctx *gin.Context) int {
+	n := fullTextMaxItems
+	if limit := parseCountParam(ctx.Query(paramLimit)); limit > 0 && limit < n {
+		if offset := parseCountParam(ctx.Query(paramOffset)); offset < n-limit {
+			n = offset + limit
+		}
+	}
+	return n
+}
+
+// fetchFullText extracts the linked article of every item in place. Items
+// whose page cannot be fetched or extracted keep their content.
+func fetchFullText(ctx context.Context, c cache.Cache, items []feed.Item) {
+	var group errgroup.Group
+	group.SetLimit(fullTextConcurrency)
+
+	for i := range items {
+		item := &items[i]
+		if !strings.HasPrefix(item.Link, "http://") && !strings.HasPrefix(item.Link, "https://") {
+			continue
+		}
+
+		group.Go(func() error {
+			article, err := getArticle(ctx, c, item.Link)
+			if err != nil {
+				utils.LogError("Failed to extract full text of %s: %v", item.Link, err)
+				return nil
+			}
+			applyArticle(item, article)
+			return nil
+		})
+	}
+
+	_ = group.Wait()
+}
+
+// getArticle returns the extracted article of a page from the cache or by
+// fetching it, sharing concurrent fetches of the same URL
+func getArticle(ctx context.Context, c cache.Cache, link string) (*extract.Article, error) {
+	extractArticle := func() (*extract.Article, error) {
+		result, err, _ := fullTextSF.Do(link, func() (interface{}, error) {
+			body, err := client.New(config.C).Get(link, nil)
+			if err != nil {
+				return nil, err
+			}
+			return extract.Extract(body, link)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.(*extract.Article), nil
+	}
+
+	if c == nil {
+		return extractArticle()
+	}
+
+	cacheKey := fmt.Sprintf("grss:fulltext:%x", sha256.Sum256([]byte(link)))
+	return cache.TryGet(ctx, c, cacheKey, extractArticle, config.C.Cache.ContentExpire)
+}
+
+// applyArticle replaces the item content with the extracted article, keeping
+// the original content as the summary
+func applyArticle(item *feed.Item, article *extract.Article) {
+	if summary := item.SummaryText(); summary != "" {
+		item.Summary = summary
+	} else {
+		item.Summary = article.Excerpt
+	}
+	item.ContentHTML = article.Content
+	item.ContentText = ""
+
+	if item.Title == "" {
+		item.Title = article.Title
+	}
+	if len(item.AllAuthors()) == 0 && article.Byline != "" {
+		item.Authors = []feed.Person{{Name: article.Byline}}
+	}
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/cache"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+)
+
+const fullTextPage = `<html><head><title>Article</title><meta name="author" content="Jane Doe"></head><body>
+<nav><a href="/">Home</a></nav>
+<article>
+  <p>The full text of the article is much longer than the teaser, and it spans several sentences.</p>
+  <p>It explains the topic in detail, with examples, references and a conclusion at the end.</p>
+</article>
+</body></html>`
+
+func TestFullText(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(fullTextPage))
+	}))
+	defer server.Close()
+
+	config.C = &config.Config{RequestTimeout: 5 * time.Second}
+	config.C.Cache.ContentExpire = time.Hour
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(FullText(cache.NewMemoryCache(10)))
+	var data *feed.Data
+	router.GET("/test", func(c *gin.Context) {
+		data = &feed.Data{
+			Title: "Test",
+			Item: []feed.Item{
+				{Title: "Article", Link: server.URL + "/article", ContentHTML: "<p>Teaser</p>"},
+				{Title: "Missing", Link: server.URL + "/missing", ContentHTML: "<p>Kept</p>"},
+				{Title: "No link"},
+			},
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test?mode=fulltext", nil))
+	}
+
+	item := data.Item[0]
+	if !strings.Contains(item.ContentHTML, "The full text of the article") || strings.Contains(item.ContentHTML, "Home") {
+		t.Errorf("Expected extracted content, got %s", item.ContentHTML)
+	}
+	if item.Summary != "Teaser" {
+		t.Errorf("Expected original content as summary, got %q", item.Summary)
+	}
+	if len(item.Authors) != 1 || item.Authors[0].Name != "Jane Doe" {
+		t.Errorf("Expected byline as author, got %+v", item.Authors)
+	}
+	if data.Item[1].ContentHTML != "<p>Kept</p>" {
+		t.Errorf("Expected content to be kept on failure, got %s", data.Item[1].ContentHTML)
+	}
+
+	// The article is cached, the missing page is fetched again
+	if count := fetches.Load(); count != 3 {
+		t.Errorf("Expected 3 fetches, got %d", count)
+	}
+
+	// Without mode=fulltext, items are untouched
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+	if data.Item[0].ContentHTML != "<p>Teaser</p>" {
+		t.Errorf("Expected original content, got %s", data.Item[0].ContentHTML)
+	}
+}
+
+func TestFullText_BeforeParameter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(fullTextPage))
+	}))
+	defer server.Close()
+
+	config.C = &config.Config{RequestTimeout: 5 * time.Second, TitleLengthLimit: 5}
+
+	// Registered as in main, so that the extracted articles are shortened
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Parameter(nil))
+	router.Use(FullText(nil))
+	var data *feed.Data
+	router.GET("/test", func(c *gin.Context) {
+		data = &feed.Data{
+			Title: "Test",
+			Item: []feed.Item{
+				{Link: server.URL + "/article", ContentHTML: "<p>Teaser</p>"},
+			},
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test?mode=fulltext&brief=20", nil))
+
+	item := data.Item[0]
+	if !strings.Contains(item.ContentHTML, "The full text") || !strings.Contains(item.ContentHTML, "…") {
+		t.Errorf("Expected truncated extracted content, got %s", item.ContentHTML)
+	}
+	if strings.Contains(item.ContentHTML, "conclusion") {
+		t.Errorf("Expected content to be cut at 20 characters, got %s", item.ContentHTML)
+	}
+	if item.Title != "Arti…" {
+		t.Errorf("Expected extracted title to be truncated, got %q", item.Title)
+	}
+}
+
+func TestFullText_Limit(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write([]byte(fullTextPage))
+	}))
+	defer server.Close()
+
+	config.C = &config.Config{RequestTimeout: 5 * time.Second}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Parameter(nil))
+	router.Use(FullText(nil))
+	router.GET("/test", func(c *gin.Context) {
+		data := &feed.Data{Title: "Test"}
+		for i := 0; i < 100; i++ {
+			data.Item = append(data.Item, feed.Item{Title: fmt.Sprintf("Item %d", i), Link: fmt.Sprintf("%s/%d", server.URL, i)})
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	tests := []struct {
+		query   string
+		fetches int32
+	}{
+		{"mode=fulltext&limit=5", 5},
+		{"mode=fulltext&limit=5&offset=10", 15},
+		{"mode=fulltext&limit=5&offset=9223372036854775807", fullTextMaxItems},
+		{"mode=fulltext", fullTextMaxItems},
+	}
+	for _, tt := range tests {
+		fetches.Store(0)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test?"+tt.query, nil))
+		if count := fetches.Load(); count != tt.fetches {
+			t.Errorf("%s: expected %d fetches, got %d", tt.query, tt.fetches, count)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+	"github.com/jean-jacket/grss/imageproxy"
+	"github.com/jean-jacket/grss/utils"
+)
+
+// imageProxyTemplate points images at the built-in image proxy
+const imageProxyTemplate = "{base}" + imageproxy.Path + "?url={url_encoded}&sig={sig}"
+
+// hotlinkRewriter rewrites image URLs through a URL template
+type hotlinkRewriter struct {
+	template string
+	include  *regexp.Regexp
+	exclude  *regexp.Regexp
+}
+
+// Hotlink middleware rewrites the image URLs of items through
+// HOTLINK_TEMPLATE, e.g. to serve them from an image CDN, or through the
+// built-in image proxy when IMAGE_PROXY_REWRITE is enabled
+func Hotlink() gin.HandlerFunc {
+	template := config.C.Hotlink.Template
+	if template == "" && config.C.ImageProxy.Rewrite && config.C.AccessKey != "" {
+		template = imageProxyTemplate
+	}
+
+	rewriter, err := newHotlinkRewriter(template, config.C.Hotlink.IncludeHosts, config.C.Hotlink.ExcludeHosts)
+	if err != nil {
+		utils.LogError("Invalid hotlink host pattern, image URLs are not rewritten: %v", err)
+	}
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		if rewriter == nil {
+			return
+		}
+
+		dataInterface, exists := c.Get(ContextKeyData)
+		if !exists {
+			return
+		}
+		data, ok := dataInterface.(*feed.Data)
+		if !ok {
+			return
+		}
+
+		base := baseURL(c)
+		rewriteItemImages(data.Item, func(imageURL string) string {
+			return rewriter.rewrite(imageURL, base)
+		})
+	}
+}
+
+// newHotlinkRewriter returns a rewriter for the template, or nil when no
+// template is configured
+func newHotlinkRewriter(template, include, exclude string) (*hotlinkRewriter, error) {
+	if template == "" {
+		return nil, nil
+	}
+
+	rewriter := &hotlinkRewriter{template: template}
+	var err error
+	if include != "" {
+		if rewriter.include, err = regexp.Compile(include); err != nil {
+			return nil, err
+		}
+	}
+	if exclude != "" {
+		if rewriter.exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, err
+		}
+	}
+	return rewriter, nil
+}
+
+// rewrite fills the template with an absolute image URL and the base URL of
+// the server. URLs of other schemes and of filtered hosts are returned
+// unchanged.
+func (r *hotlinkRewriter) rewrite(imageURL, base string) string {
+	u, err := url.Parse(imageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return imageURL
+	}
+
+	host := u.Hostname()
+	if r.include != nil && !r.include.MatchString(host) {
+		return imageURL
+	}
+	if r.exclude != nil && r.exclude.MatchString(host) {
+		return imageURL
+	}
+
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	replacements := []string{
+		"{url_encoded}", url.QueryEscape(imageURL),
+		"{url}", imageURL,
+		"{host}", u.Host,
+		"{path}", path,
+		"{base}", base,
+	}
+	if strings.Contains(r.template, "{sig}") {
+		replacements = append(replacements, "{sig}", imageproxy.Sign(imageURL))
+	}
+	return strings.NewReplacer(replacements...).Replace(r.template)
+}
+
+// rewriteItemImages passes the images in the item content, the Media RSS
+// thumbnail and image and the image attachments through rewrite. Relative
+// URLs are resolved against the item link first.
+func rewriteItemImages(items []feed.Item, rewrite func(string) string) {
+	for i := range items {
+		item := &items[i]
+
+		rewriteURL := func(ref string) string {
+			resolved := resolveImageURL(item.Link, ref)
+			if rewritten := rewrite(resolved); rewritten != resolved {
+				return rewritten
+			}
+			return ref
+		}
+
+		item.ContentHTML = utils.RewriteImageURLs(item.ContentHTML, rewriteURL)
+		item.Description = utils.RewriteImageURLs(item.Description, rewriteURL)
+
+		if media := item.Media; media != nil {
+			if media.Thumbnail != nil {
+				media.Thumbnail.URL = rewriteURL(media.Thumbnail.URL)
+			}
+			if content := media.Content; content != nil && (content.Medium == "image" || isImageType(content.Type)) {
+				content.URL = rewriteURL(content.URL)
+			}
+		}
+
+		if item.EnclosureURL != "" && isImageType(item.EnclosureType) {
+			item.EnclosureURL = rewriteURL(item.EnclosureURL)
+		}
+		for j := range item.Attachments {
+			if isImageType(item.Attachments[j].Type) {
+				item.Attachments[j].URL = rewriteURL(item.Attachments[j].URL)
+			}
+		}
+	}
+}
+
+// resolveImageURL resolves an image reference against the page it appears on
+func resolveImageURL(base, ref string) string {
+	refURL, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil || refURL.IsAbs() || base == "" {
+		return ref
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// isImageType reports whether a MIME type is an image type
+func isImageType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "image/")
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+	"github.com/jean-jacket/grss/imageproxy"
+)
+
+func TestHotlinkRewriter(t *testing.T) {
+	rewriter, err := newHotlinkRewriter("https://cdn.example.net/{host}{path}?src={url_encoded}", "", `^static\.example\.com$`)
+	if err != nil {
+		t.Fatalf("newHotlinkRewriter failed: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"https://img.example.com/a/b.png?w=100", "https://cdn.example.net/img.example.com/a/b.png?w=100?src=https%3A%2F%2Fimg.example.com%2Fa%2Fb.png%3Fw%3D100"},
+		{"https://static.example.com/logo.png", "https://static.example.com/logo.png"},
+		{"data:image/png;base64,AAAA", "data:image/png;base64,AAAA"},
+		{"/relative.png", "/relative.png"},
+	}
+	for _, tt := range tests {
+		if result := rewriter.rewrite(tt.input, ""); result != tt.expected {
+			t.Errorf("rewrite(%q) = %q, expected %q", tt.input, result, tt.expected)
+		}
+	}
+
+	include, _ := newHotlinkRewriter("https://proxy.example.net/{url}", `\.example\.com$`, "")
+	if result := include.rewrite("https://other.org/a.png", ""); result != "https://other.org/a.png" {
+		t.Errorf("Expected hosts outside the include pattern to be kept, got %q", result)
+	}
+
+	if _, err := newHotlinkRewriter("https://proxy.example.net/{url}", "(", ""); err == nil {
+		t.Error("Expected error for invalid host pattern")
+	}
+	if rewriter, _ := newHotlinkRewriter("", "", ""); rewriter != nil {
+		t.Error("Expected no rewriter without template")
+	}
+}
+
+func TestHotlink(t *testing.T) {
+	config.C = &config.Config{}
+	config.C.Hotlink.Template = "https://proxy.example.net/{url}"
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Hotlink())
+	var data *feed.Data
+	router.GET("/test", func(c *gin.Context) {
+		data = &feed.Data{
+			Title: "Test",
+			Item: []feed.Item{
+				{
+					Title:       "Item",
+					Link:        "https://example.com/posts/1",
+					ContentHTML: `<p>Text <a href="https://example.com/x.png">link</a></p><img src="/img/a.png" alt="A"><picture><source srcset="https://example.com/b.webp 1x, https://example.com/b@2x.webp 2x"></picture>`,
+					Media: &feed.Media{
+						Thumbnail: &feed.MediaThumbnail{URL: "https://example.com/thumb.jpg"},
+						Content:   &feed.MediaContent{URL: "https://example.com/video.mp4", Type: "video/mp4"},
+					},
+					Attachments: []feed.Attachment{
+						{URL: "https://example.com/cover.jpg", Type: "image/jpeg"},
+						{URL: "https://example.com/episode.mp3", Type: "audio/mpeg"},
+					},
+				},
+			},
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+	item := data.Item[0]
+	expected := `<p>Text <a href="https://example.com/x.png">link</a></p><img src="https://proxy.example.net/https://example.com/img/a.png" alt="A"><picture><source srcset="https://proxy.example.net/https://example.com/b.webp 1x, https://proxy.example.net/https://example.com/b@2x.webp 2x"></picture>`
+	if item.ContentHTML != expected {
+		t.Errorf("Wrong content:\n%s\nexpected:\n%s", item.ContentHTML, expected)
+	}
+	if item.Media.Thumbnail.URL != "https://proxy.example.net/https://example.com/thumb.jpg" {
+		t.Errorf("Expected rewritten thumbnail, got %s", item.Media.Thumbnail.URL)
+	}
+	if item.Media.Content.URL != "https://example.com/video.mp4" {
+		t.Errorf("Expected video to be kept, got %s", item.Media.Content.URL)
+	}
+	if item.Attachments[0].URL != "https://proxy.example.net/https://example.com/cover.jpg" || item.Attachments[1].URL != "https://example.com/episode.mp3" {
+		t.Errorf("Expected only image attachments to be rewritten, got %+v", item.Attachments)
+	}
+}
+
+func TestHotlink_ImageProxy(t *testing.T) {
+	config.C = &config.Config{AccessKey: "secret"}
+	config.C.ImageProxy.Rewrite = true
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Hotlink())
+	var data *feed.Data
+	router.GET("/test", func(c *gin.Context) {
+		data = &feed.Data{
+			Title: "Test",
+			Item: []feed.Item{
+				{Title: "Item", ContentHTML: `<img src="https://example.com/a.png">`},
+			},
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Host = "grss.example.com"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	proxied := imageproxy.URL("http://grss.example.com", "https://example.com/a.png")
+	expected := `<img src="` + strings.ReplaceAll(proxied, "&", "&amp;") + `">`
+	if data.Item[0].ContentHTML != expected {
+		t.Errorf("Expected image proxy URL, got %s", data.Item[0].ContentHTML)
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/dlclark/regexp2"
+)
+
+// filterMatchBudget is the total time the filter patterns of a request may
+// spend matching with the backtracking engine
+const filterMatchBudget = time.Second
+
+// errMatchBudget is returned when the filter patterns of a request exceed
+// filterMatchBudget
+var errMatchBudget = errors.New("filter patterns exceeded the matching time budget")
+
+// matcher matches a filter pattern against item fields
+type matcher interface {
+	MatchString(s string) (bool, error)
+}
+
+// compileMatcher compiles a pattern with the engine selected by
+// FILTER_REGEX_ENGINE: "regexp" for the backtracking engine, which supports
+// lookarounds and backreferences, and RE2 otherwise
+func compileMatcher(engine, pattern string, caseSensitive bool, budget *matchBudget) (matcher, error) {
+	if engine == "regexp" {
+		options := regexp2.None
+		if !caseSensitive {
+			options = regexp2.IgnoreCase
+		}
+		re, err := regexp2.Compile(pattern, options)
+		if err != nil {
+			return nil, err
+		}
+		return &backtrackMatcher{re: re, budget: budget}, nil
+	}
+
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return re2Matcher{re: re}, nil
+}
+
+// re2Matcher matches with Go's regexp package, which runs in linear time
+type re2Matcher struct {
+	re *regexp.Regexp
+}
+
+func (m re2Matcher) MatchString(s string) (bool, error) {
+	return m.re.MatchString(s), nil
+}
+
+// backtrackMatcher matches with a PCRE-like backtracking engine, bounded by
+// the time budget of the request
+type backtrackMatcher struct {
+	re     *regexp2.Regexp
+	budget *matchBudget
+}
+
+func (m *backtrackMatcher) MatchString(s string) (bool, error) {
+	remaining := m.budget.remaining()
+	if remaining <= 0 {
+		return false, errMatchBudget
+	}
+
+	m.re.MatchTimeout = remaining
+	matched, err := m.re.MatchString(s)
+	if err != nil {
+		return false, errMatchBudget
+	}
+	return matched, nil
+}
+
+// matchBudget is the matching time shared by the patterns of a request. The
+// clock starts on the first match, so time spent in the route handler does
+// not count.
+type matchBudget struct {
+	limit    time.Duration
+	deadline time.Time
+}
+
+// newMatchBudget creates a budget of limit
+func newMatchBudget(limit time.Duration) *matchBudget {
+	return &matchBudget{limit: limit}
+}
+
+// remaining returns the matching time left
+func (b *matchBudget) remaining() time.Duration {
+	if b.deadline.IsZero() {
+		b.deadline = time.Now().Add(b.limit)
+	}
+	return time.Until(b.deadline)
+}
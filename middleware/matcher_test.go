@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+)
+
+func TestCompileMatcher(t *testing.T) {
+	// Lookarounds and backreferences need the backtracking engine
+	if _, err := compileMatcher("re2", `^(?!Re:)`, true, newMatchBudget(filterMatchBudget)); err == nil {
+		t.Error("Expected RE2 to reject lookaheads")
+	}
+
+	tests := []struct {
+		pattern  string
+		input    string
+		expected bool
+	}{
+		{`^(?!Re:).*release`, "Go 1.24 release", true},
+		{`^(?!Re:).*release`, "Re: Go 1.24 release", false},
+		{`\b(\w+) \1\b`, "the the typo", true},
+		{`\b(\w+) \1\b`, "no typo here", false},
+		{`(?i)GOLANG`, "golang weekly", true},
+	}
+	for _, tt := range tests {
+		m, err := compileMatcher("regexp", tt.pattern, true, newMatchBudget(filterMatchBudget))
+		if err != nil {
+			t.Fatalf("compileMatcher(%q) failed: %v", tt.pattern, err)
+		}
+		matched, err := m.MatchString(tt.input)
+		if err != nil {
+			t.Fatalf("MatchString(%q) failed: %v", tt.input, err)
+		}
+		if matched != tt.expected {
+			t.Errorf("%q matching %q = %v, expected %v", tt.pattern, tt.input, matched, tt.expected)
+		}
+	}
+
+	if _, err := compileMatcher("regexp", `(unclosed`, true, newMatchBudget(filterMatchBudget)); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+func TestBacktrackMatcher_Budget(t *testing.T) {
+	budget := newMatchBudget(50 * time.Millisecond)
+	m, err := compileMatcher("regexp", `^(a+)+$`, true, budget)
+	if err != nil {
+		t.Fatalf("compileMatcher failed: %v", err)
+	}
+
+	start := time.Now()
+	_, err = m.MatchString(strings.Repeat("a", 64) + "!")
+	if !errors.Is(err, errMatchBudget) {
+		t.Errorf("Expected errMatchBudget, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Match ran for %v despite the budget", elapsed)
+	}
+
+	// The budget is shared, so later matches fail immediately
+	if _, err := m.MatchString("aaa"); !errors.Is(err, errMatchBudget) {
+		t.Errorf("Expected exhausted budget, got %v", err)
+	}
+}
+
+func TestParameter_RegexpEngine(t *testing.T) {
+	config.C = &config.Config{FilterRegexEngine: "regexp"}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Parameter(nil))
+	var data *feed.Data
+	router.GET("/test", func(c *gin.Context) {
+		data = &feed.Data{
+			Title: "Test",
+			Item: []feed.Item{
+				{Title: "Go 1.24 release"},
+				{Title: "Re: Go 1.24 release"},
+			},
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test?filter_title=%5E(%3F!Re%3A)", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(data.Item) != 1 || data.Item[0].Title != "Go 1.24 release" {
+		t.Errorf("Expected only the non-reply item, got %+v", data.Item)
+	}
+}
@@ -1,19 +1,118 @@
 package middleware
 
 import (
-	"regexp"
-	"sort"
+	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/cache"
+	"github.com/jean-jacket/grss/config"
 	"github.com/jean-jacket/grss/feed"
 	"github.com/jean-jacket/grss/utils"
 )
 
-// Parameter middleware processes query parameters (filter, limit, etc.)
-func Parameter() gin.HandlerFunc {
+// filterParams maps the filter query parameters to the item fields they
+// match. The filterout parameters drop the matching items instead.
+var filterParams = []struct {
+	param   string
+	fields  []string
+	inverse bool
+}{
+	{"filter", []string{"title", "description"}, false},
+	{"filter_title", []string{"title"}, false},
+	{"filter_description", []string{"description"}, false},
+	{"filter_author", []string{"author"}, false},
+	{"filter_category", []string{"category"}, false},
+	{"filterout", []string{"title", "description"}, true},
+	{"filterout_title", []string{"title"}, true},
+	{"filterout_description", []string{"description"}, true},
+	{"filterout_author", []string{"author"}, true},
+	{"filterout_category", []string{"category"}, true},
+}
+
+// itemFilter is a compiled filter query parameter
+type itemFilter struct {
+	match   matcher
+	fields  []string
+	inverse bool
+}
+
+// Parameter middleware processes query parameters (filter, limit, etc.).
+// First-seen times for date_fallback are stored in store, which may be nil.
+func Parameter(store cache.Cache) gin.HandlerFunc {
+	var fileRules []rewriteRule
+	if config.C.RewriteRules != "" {
+		var err error
+		if fileRules, err = loadRewriteRules(config.C.RewriteRules); err != nil {
+			utils.LogError("Failed to load rewrite rules, items are not rewritten: %v", err)
+		}
+	}
+
 	return func(c *gin.Context) {
+		// Reject invalid filter patterns, sort keys and date parameters
+		// before running the handler
+		filters, expr, err := compileFilters(c)
+		if err != nil {
+			abortWithParameterError(c, err.Error())
+			return
+		}
+
+		var sortKeys []sortKey
+		if sorted := c.Query(paramSorted); sorted != "" {
+			if sortKeys, err = parseSortKeys(sorted); err != nil {
+				abortWithParameterError(c, err.Error())
+				return
+			}
+		}
+
+		var dedupKey func(item *feed.Item) string
+		if dedup := c.Query(paramDedup); dedup != "" {
+			if dedupKey = dedupKeys[dedup]; dedupKey == nil {
+				abortWithParameterError(c, "unknown dedup mode: "+dedup)
+				return
+			}
+		}
+
+		var rules []rewriteRule
+		for _, rule := range fileRules {
+			if rule.route == nil || rule.route.MatchString(c.Request.URL.Path) {
+				rules = append(rules, rule)
+			}
+		}
+		for _, param := range c.QueryArray(paramRewrite) {
+			rule, err := parseRewriteRule(param)
+			if err != nil {
+				abortWithParameterError(c, err.Error())
+				return
+			}
+			rules = append(rules, rule)
+		}
+
+		dateFallback := c.Query(paramDateFallback)
+		if dateFallback != "" && !dateFallbacks[dateFallback] {
+			abortWithParameterError(c, "unknown date fallback: "+dateFallback)
+			return
+		}
+
+		var digestPeriod string
+		if c.Query(paramMode) == "digest" {
+			digestPeriod = c.DefaultQuery(paramPeriod, "day")
+			if !digestPeriods[digestPeriod] {
+				abortWithParameterError(c, "unknown digest period: "+digestPeriod)
+				return
+			}
+		}
+
+		loc := time.UTC
+		if tz := c.Query(paramTimezone); tz != "" {
+			if loc, err = time.LoadLocation(tz); err != nil {
+				abortWithParameterError(c, "unknown timezone: "+tz)
+				return
+			}
+		}
+
 		// Process request first
 		c.Next()
 
@@ -28,125 +127,193 @@ func Parameter() gin.HandlerFunc {
 			return
 		}
 
-		// Apply filters and transformations
+		// Rewrite fields before anything looks at them
 		items := data.Item
+		if len(rules) > 0 {
+			rewriteItems(items, rules)
+		}
 
-		// Filter by regex
-		if filter := c.Query("filter"); filter != "" {
-			items = filterItems(items, filter, false)
+		// Date items without a publication date
+		now := time.Now()
+		if dateFallback != "" {
+			items = applyDateFallback(c.Request.Context(), store, c.Request.URL.Path, items, dateFallback, now)
 		}
 
-		// Filter out by regex
-		if filterOut := c.Query("filterout"); filterOut != "" {
-			items = filterItems(items, filterOut, true)
+		// Apply filters and transformations
+		items, err = applyFilters(items, filters, expr, now)
+		if err != nil {
+			// Drop the data so that no feed is rendered
+			delete(c.Keys, ContextKeyData)
+			abortWithParameterError(c, err.Error())
+			return
 		}
 
-		// Filter by title
-		if filterTitle := c.Query("filter_title"); filterTitle != "" {
-			items = filterByTitle(items, filterTitle, false)
+		// Filter by time
+		if filterTime := c.Query(paramFilterTime); filterTime != "" {
+			items = filterByTime(items, filterTime)
 		}
 
-		// Filter by description
-		if filterDesc := c.Query("filter_description"); filterDesc != "" {
-			items = filterByDescription(items, filterDesc, false)
+		// Collapse duplicates, keeping the first
+		if dedupKey != nil {
+			items = dedupItems(items, dedupKey)
 		}
 
-		// Filter by time
-		if filterTime := c.Query("filter_time"); filterTime != "" {
-			items = filterByTime(items, filterTime)
+		// Group items into periodic digests
+		if digestPeriod != "" {
+			items = digestItems(data, items, digestPeriod, loc, c.Request.URL.Path)
 		}
 
 		// Sort items
-		if sorted := c.Query("sorted"); sorted != "" {
-			items = sortItems(items, sorted)
+		if sortKeys != nil {
+			items = sortItems(items, sortKeys)
+		}
+
+		// Skip items
+		if offset := parseCountParam(c.Query(paramOffset)); offset > 0 {
+			items = items[min(offset, len(items)):]
 		}
 
 		// Limit items
-		if limitStr := c.Query("limit"); limitStr != "" {
-			if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-				if limit < len(items) {
-					items = items[:limit]
-				}
-			}
+		if limit := parseCountParam(c.Query(paramLimit)); limit > 0 && limit < len(items) {
+			items = items[:limit]
 		}
 
-		// Update data with processed items
+		// Shorten the content of the remaining items
+		if brief := parseCountParam(c.Query(paramBrief)); brief > 0 {
+			items = briefItems(items, brief)
+		}
+
+		// Truncate titles
+		if config.C.TitleLengthLimit > 0 {
+			items = truncateTitles(items, config.C.TitleLengthLimit)
+		}
+
+		// Update data with processed items, rendering dates in the
+		// requested timezone
 		data.Item = items
+		localizeDates(data, loc)
 		c.Set(ContextKeyData, data)
 	}
 }
 
-// filterItems filters items by regex pattern
-func filterItems(items []feed.Item, pattern string, inverse bool) []feed.Item {
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		utils.LogError("Invalid regex pattern: %v", err)
-		return items
+// compileFilters compiles the filter query parameters and the expr
+// expression of a request with the configured engine. Patterns are case
+// sensitive unless filter_case_sensitive=false.
+func compileFilters(c *gin.Context) ([]itemFilter, exprNode, error) {
+	caseSensitive := parseCaseSensitiveParam(c.Query(paramFilterCaseSensitive))
+	budget := newMatchBudget(filterMatchBudget)
+	compile := func(pattern string) (matcher, error) {
+		return compileMatcher(config.C.FilterRegexEngine, pattern, caseSensitive, budget)
 	}
 
-	filtered := []feed.Item{}
-	for _, item := range items {
-		// Check if pattern matches title or description
-		matches := re.MatchString(item.Title) || re.MatchString(item.Description)
-
-		if inverse {
-			matches = !matches
+	var filters []itemFilter
+	for _, fp := range filterParams {
+		pattern := c.Query(fp.param)
+		if pattern == "" {
+			continue
+		}
+		m, err := compile(pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s pattern: %v", fp.param, err)
 		}
+		filters = append(filters, itemFilter{match: m, fields: fp.fields, inverse: fp.inverse})
+	}
 
-		if matches {
-			filtered = append(filtered, item)
+	var expr exprNode
+	if src := c.Query(paramExpr); src != "" {
+		var err error
+		if expr, err = parseExpr(src, compile, caseSensitive); err != nil {
+			return nil, nil, fmt.Errorf("invalid expr: %v", err)
 		}
 	}
 
-	return filtered
+	return filters, expr, nil
 }
 
-// filterByTitle filters items by title regex
-func filterByTitle(items []feed.Item, pattern string, inverse bool) []feed.Item {
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		utils.LogError("Invalid regex pattern: %v", err)
-		return items
+// applyFilters applies the compiled filters in order, then the expression
+func applyFilters(items []feed.Item, filters []itemFilter, expr exprNode, now time.Time) ([]feed.Item, error) {
+	var err error
+	for _, f := range filters {
+		if items, err = filterItems(items, f.match, f.fields, f.inverse); err != nil {
+			return nil, err
+		}
+	}
+
+	if expr != nil {
+		if items, err = filterByExpr(items, expr, now); err != nil {
+			return nil, err
+		}
 	}
 
+	return items, nil
+}
+
+// filterItems keeps the items for which any of the fields matches, or none
+// does when inverse is set
+func filterItems(items []feed.Item, m matcher, fields []string, inverse bool) ([]feed.Item, error) {
 	filtered := []feed.Item{}
-	for _, item := range items {
-		matches := re.MatchString(item.Title)
+	for i := range items {
+		matches := false
+	fieldLoop:
+		for _, field := range fields {
+			for _, value := range exprFields[field](&items[i]) {
+				matched, err := m.MatchString(value)
+				if err != nil {
+					return nil, err
+				}
+				if matched {
+					matches = true
+					break fieldLoop
+				}
+			}
+		}
 
 		if inverse {
 			matches = !matches
 		}
 
 		if matches {
-			filtered = append(filtered, item)
+			filtered = append(filtered, items[i])
 		}
 	}
 
-	return filtered
+	return filtered, nil
 }
 
-// filterByDescription filters items by description regex
-func filterByDescription(items []feed.Item, pattern string, inverse bool) []feed.Item {
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		utils.LogError("Invalid regex pattern: %v", err)
-		return items
-	}
-
+// filterByExpr keeps the items for which the expression is true
+func filterByExpr(items []feed.Item, expr exprNode, now time.Time) ([]feed.Item, error) {
 	filtered := []feed.Item{}
-	for _, item := range items {
-		matches := re.MatchString(item.Description)
-
-		if inverse {
-			matches = !matches
+	for i := range items {
+		matches, err := expr.eval(&exprEnv{item: &items[i], now: now})
+		if err != nil {
+			return nil, err
 		}
-
 		if matches {
-			filtered = append(filtered, item)
+			filtered = append(filtered, items[i])
 		}
 	}
 
-	return filtered
+	return filtered, nil
+}
+
+// abortWithParameterError responds with a 400 JSON error like the Template
+// middleware does for unknown formats
+func abortWithParameterError(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": gin.H{
+			"message": message,
+		},
+	})
+	c.Abort()
+}
+
+// itemContent returns the text matched by description filters: the summary
+// followed by the HTML content
+func itemContent(item *feed.Item) string {
+	if item.Summary == "" {
+		return item.HTML()
+	}
+	return item.Summary + "\n" + item.HTML()
 }
 
 // filterByTime filters items by time (only items newer than N seconds)
@@ -169,17 +336,27 @@ func filterByTime(items []feed.Item, timeStr string) []feed.Item {
 	return filtered
 }
 
-// sortItems sorts items by publication date
-func sortItems(items []feed.Item, order string) []feed.Item {
-	sorted := make([]feed.Item, len(items))
-	copy(sorted, items)
+// ellipsis marks truncated titles and content
+const ellipsis = "…"
 
-	sort.Slice(sorted, func(i, j int) bool {
-		if order == "desc" {
-			return sorted[i].PubDate.After(sorted[j].PubDate)
-		}
-		return sorted[i].PubDate.Before(sorted[j].PubDate)
-	})
+// truncateTitles shortens titles to at most maxLen runes
+func truncateTitles(items []feed.Item, maxLen int) []feed.Item {
+	for i := range items {
+		items[i].Title = utils.Truncate(items[i].Title, maxLen, ellipsis)
+	}
+	return items
+}
 
-	return sorted
+// briefItems shortens the summary and content of items to at most maxLen
+// characters of text. HTML content is cut between tags and the tags left
+// open are closed.
+func briefItems(items []feed.Item, maxLen int) []feed.Item {
+	for i := range items {
+		item := &items[i]
+		item.Summary = utils.Truncate(item.Summary, maxLen, ellipsis)
+		item.ContentHTML = utils.TruncateHTML(item.ContentHTML, maxLen, ellipsis)
+		item.ContentText = utils.Truncate(item.ContentText, maxLen, ellipsis)
+		item.Description = utils.TruncateHTML(item.Description, maxLen, ellipsis)
+	}
+	return items
 }
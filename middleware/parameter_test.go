@@ -1,9 +1,14 @@
 package middleware
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
 	"github.com/jean-jacket/grss/feed"
 )
 
@@ -15,13 +20,13 @@ func TestFilterItems(t *testing.T) {
 	}
 
 	// Filter for "bug"
-	filtered := filterItems(items, "(?i)bug", false)
+	filtered, _ := filterItems(items, mustCompile(t, "(?i)bug"), []string{"title", "description"}, false)
 	if len(filtered) != 2 {
 		t.Errorf("Expected 2 items with 'bug', got %d", len(filtered))
 	}
 
 	// Filter out "bug"
-	filtered = filterItems(items, "(?i)bug", true)
+	filtered, _ = filterItems(items, mustCompile(t, "(?i)bug"), []string{"title", "description"}, true)
 	if len(filtered) != 1 {
 		t.Errorf("Expected 1 item without 'bug', got %d", len(filtered))
 	}
@@ -38,7 +43,7 @@ func TestFilterByTitle(t *testing.T) {
 	}
 
 	// Filter by title only
-	filtered := filterByTitle(items, "(?i)bug", false)
+	filtered, _ := filterItems(items, mustCompile(t, "(?i)bug"), []string{"title"}, false)
 	if len(filtered) != 2 {
 		t.Errorf("Expected 2 items, got %d", len(filtered))
 	}
@@ -57,7 +62,7 @@ func TestFilterByDescription(t *testing.T) {
 		{Title: "Normal title", Description: "Description with bug"},
 	}
 
-	filtered := filterByDescription(items, "(?i)bug", false)
+	filtered, _ := filterItems(items, mustCompile(t, "(?i)bug"), []string{"description"}, false)
 	if len(filtered) != 1 {
 		t.Errorf("Expected 1 item, got %d", len(filtered))
 	}
@@ -95,7 +100,7 @@ func TestSortItems(t *testing.T) {
 	}
 
 	// Sort ascending
-	sorted := sortItems(items, "asc")
+	sorted := sortItems(items, []sortKey{{field: "date"}})
 	if sorted[0].Title != "Oldest" {
 		t.Errorf("Expected 'Oldest' first in asc sort, got '%s'", sorted[0].Title)
 	}
@@ -104,7 +109,7 @@ func TestSortItems(t *testing.T) {
 	}
 
 	// Sort descending
-	sorted = sortItems(items, "desc")
+	sorted = sortItems(items, []sortKey{{field: "date", desc: true}})
 	if sorted[0].Title != "Newest" {
 		t.Errorf("Expected 'Newest' first in desc sort, got '%s'", sorted[0].Title)
 	}
@@ -113,16 +118,40 @@ func TestSortItems(t *testing.T) {
 	}
 }
 
-func TestFilterItems_InvalidRegex(t *testing.T) {
-	items := []feed.Item{
-		{Title: "Test"},
+func TestParameter_InvalidPattern(t *testing.T) {
+	config.C = &config.Config{}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Parameter(nil))
+	handlerCalled := false
+	router.GET("/test", func(c *gin.Context) {
+		handlerCalled = true
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test?filter=%5Binvalid(regex", nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid pattern, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "invalid filter pattern") {
+		t.Errorf("Expected the compile error in the response, got %s", w.Body.String())
 	}
+	if handlerCalled {
+		t.Error("Handler should not run for an invalid pattern")
+	}
+}
 
-	// Invalid regex should return all items unchanged
-	filtered := filterItems(items, "[invalid(regex", false)
-	if len(filtered) != len(items) {
-		t.Error("Invalid regex should return all items")
+// mustCompile compiles an RE2 filter pattern
+func mustCompile(t *testing.T, pattern string) matcher {
+	t.Helper()
+	m, err := compileMatcher("re2", pattern, true, newMatchBudget(filterMatchBudget))
+	if err != nil {
+		t.Fatalf("compileMatcher(%q) failed: %v", pattern, err)
 	}
+	return m
 }
 
 func TestFilterByTime_InvalidTime(t *testing.T) {
@@ -136,3 +165,72 @@ func TestFilterByTime_InvalidTime(t *testing.T) {
 		t.Error("Invalid time should return all items")
 	}
 }
+
+func TestFilterItems_ContentFields(t *testing.T) {
+	items := []feed.Item{
+		{Title: "First", Summary: "Mentions golang"},
+		{Title: "Second", ContentHTML: "<p>Also golang</p>"},
+		{Title: "Third", ContentText: "golang in text"},
+		{Title: "Fourth", ContentHTML: "<p>Nothing</p>"},
+	}
+
+	filtered, _ := filterItems(items, mustCompile(t, "golang"), []string{"description"}, false)
+	if len(filtered) != 3 {
+		t.Errorf("Expected 3 items matching summary or content, got %d", len(filtered))
+	}
+}
+
+func TestBriefItems(t *testing.T) {
+	items := []feed.Item{
+		{
+			Summary:     "Über die Straße gehen",
+			ContentHTML: "<p>Hello <b>wörld</b> and <i>everyone</i> else</p><p>More</p>",
+			ContentText: "Hello wörld and everyone else",
+		},
+		{ContentHTML: "<p>Short</p>"},
+	}
+
+	items = briefItems(items, 12)
+	if items[0].Summary != "Über die St…" {
+		t.Errorf("Unexpected summary: %q", items[0].Summary)
+	}
+	if items[0].ContentHTML != "<p>Hello <b>wörld</b>…</p>" {
+		t.Errorf("Unexpected HTML content: %q", items[0].ContentHTML)
+	}
+	if items[0].ContentText != "Hello wörld…" {
+		t.Errorf("Unexpected text content: %q", items[0].ContentText)
+	}
+	if items[1].ContentHTML != "<p>Short</p>" {
+		t.Errorf("Short content should be kept, got %q", items[1].ContentHTML)
+	}
+}
+
+func TestParameter_TitleAndBrief(t *testing.T) {
+	config.C = &config.Config{TitleLengthLimit: 10}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Parameter(nil))
+	var data *feed.Data
+	router.GET("/test", func(c *gin.Context) {
+		data = &feed.Data{
+			Title: "Test",
+			Item: []feed.Item{
+				{Title: "日本語のとても長いタイトルです", ContentHTML: "<p>Some <a href=\"/x\">linked &amp; long</a> text</p>"},
+			},
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test?brief=8", nil)
+	router.ServeHTTP(w, req)
+
+	item := data.Item[0]
+	if item.Title != "日本語のとても長い…" {
+		t.Errorf("Unexpected title: %q", item.Title)
+	}
+	if item.ContentHTML != "<p>Some <a href=\"/x\">li…</a></p>" {
+		t.Errorf("Unexpected content: %q", item.ContentHTML)
+	}
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import "strconv"
+
+// Query parameters read by the middlewares, besides the filter parameters
+// listed in filterParams
+const (
+	paramFormat              = "format"
+	paramMode                = "mode"
+	paramPeriod              = "period"
+	paramLimit               = "limit"
+	paramOffset              = "offset"
+	paramBrief               = "brief"
+	paramSorted              = "sorted"
+	paramDedup               = "dedup"
+	paramRewrite             = "rewrite"
+	paramExpr                = "expr"
+	paramFilterCaseSensitive = "filter_case_sensitive"
+	paramFilterTime          = "filter_time"
+	paramTimezone            = "tz"
+	paramDateFallback        = "date_fallback"
+)
+
+// outputParams maps the query parameters that change the output of a route
+// to the normalization of their values. Together with the QueryParameters a
+// route declares, they make up the cache key, so unrelated parameters such
+// as tracking tags do not split the cache and equivalent values such as
+// limit=05 and limit=5 share an entry. A parameter normalized to "" has no
+// effect and is left out. The format is keyed by its negotiated name.
+var outputParams = map[string]func(string) string{
+	paramMode:                keepParam,
+	paramPeriod:              keepParam,
+	paramLimit:               normalizeCountParam,
+	paramOffset:              normalizeCountParam,
+	paramBrief:               normalizeCountParam,
+	paramSorted:              keepParam,
+	paramDedup:               keepParam,
+	paramRewrite:             keepParam,
+	paramExpr:                keepParam,
+	paramFilterCaseSensitive: normalizeCaseSensitiveParam,
+	paramFilterTime:          normalizeIntParam,
+	paramTimezone:            keepParam,
+	paramDateFallback:        keepParam,
+}
+
+func init() {
+	for _, fp := range filterParams {
+		outputParams[fp.param] = keepParam
+	}
+}
+
+// keepParam keeps a parameter value as is
+func keepParam(value string) string {
+	return value
+}
+
+// parseCountParam parses a limit, offset or brief value. Values that are not
+// positive integers are ignored and parse as 0.
+func parseCountParam(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// normalizeCountParam formats the value parseCountParam reads
+func normalizeCountParam(value string) string {
+	if n := parseCountParam(value); n > 0 {
+		return strconv.Itoa(n)
+	}
+	return ""
+}
+
+// normalizeIntParam formats an integer value, dropping invalid ones
+func normalizeIntParam(value string) string {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// parseCaseSensitiveParam parses filter_case_sensitive. Filters are case
+// sensitive unless it is a false boolean value.
+func parseCaseSensitiveParam(value string) bool {
+	caseSensitive, err := strconv.ParseBool(value)
+	return err != nil || caseSensitive
+}
+
+// normalizeCaseSensitiveParam formats the value parseCaseSensitiveParam
+// reads, dropping the default
+func normalizeCaseSensitiveParam(value string) string {
+	if parseCaseSensitiveParam(value) {
+		return ""
+	}
+	return "false"
+}
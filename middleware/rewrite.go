@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/jean-jacket/grss/feed"
+)
+
+// rewriteFields lists the item fields rewrite rules can apply to
+var rewriteFields = map[string]bool{
+	"title":       true,
+	"link":        true,
+	"description": true,
+	"author":      true,
+}
+
+// rewriteRule replaces matches of a pattern in one item field. Patterns use
+// RE2 syntax and replacements may reference groups as $1 or ${name}.
+type rewriteRule struct {
+	route   *regexp.Regexp // Request paths the rule applies to, nil for all
+	field   string
+	pattern *regexp.Regexp
+	replace string
+	all     bool // Replace every match instead of the first
+}
+
+// rewriteRuleConfig is a rule in the REWRITE_RULES file
+type rewriteRuleConfig struct {
+	Route   string `json:"route,omitempty"`
+	Field   string `json:"field"`
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace"`
+	Flags   string `json:"flags,omitempty"`
+}
+
+// newRewriteRule compiles a rule. Flags are "i" for case-insensitive
+// matching and "g" to replace every match.
+func newRewriteRule(field, pattern, replace, flags string) (rewriteRule, error) {
+	if !rewriteFields[field] {
+		return rewriteRule{}, fmt.Errorf("unknown rewrite field: %s", field)
+	}
+
+	rule := rewriteRule{field: field, replace: replace}
+	for _, flag := range flags {
+		switch flag {
+		case 'i':
+			pattern = "(?i)" + pattern
+		case 'g':
+			rule.all = true
+		default:
+			return rewriteRule{}, fmt.Errorf("unknown rewrite flag: %c", flag)
+		}
+	}
+
+	var err error
+	if rule.pattern, err = regexp.Compile(pattern); err != nil {
+		return rewriteRule{}, fmt.Errorf("invalid rewrite pattern: %v", err)
+	}
+	return rule, nil
+}
+
+// loadRewriteRules reads the rules of a REWRITE_RULES file, a JSON array of
+// objects with a field, pattern, replace and optional flags and route regex
+func loadRewriteRules(path string) ([]rewriteRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []rewriteRuleConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, err
+	}
+
+	rules := make([]rewriteRule, 0, len(configs))
+	for i, cfg := range configs {
+		rule, err := newRewriteRule(cfg.Field, cfg.Pattern, cfg.Replace, cfg.Flags)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %v", i+1, err)
+		}
+		if cfg.Route != "" {
+			if rule.route, err = regexp.Compile(cfg.Route); err != nil {
+				return nil, fmt.Errorf("rule %d: invalid route pattern: %v", i+1, err)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseRewriteRule parses a rewrite query parameter in sed syntax prefixed
+// with the field, e.g. title:s/^\[Sponsored\] *//i. Any punctuation can
+// delimit the parts, such as link:s|utm_[^&#]*&?||g, and is escaped with a
+// backslash inside them.
+func parseRewriteRule(s string) (rewriteRule, error) {
+	field, expr, ok := strings.Cut(s, ":")
+	if !ok || !strings.HasPrefix(expr, "s") || len(expr) < 2 {
+		return rewriteRule{}, fmt.Errorf("invalid rewrite rule, expected field:s/pattern/replacement/flags: %s", s)
+	}
+
+	delim := []rune(expr[1:])[0]
+	if unicode.IsLetter(delim) || unicode.IsDigit(delim) || unicode.IsSpace(delim) || delim == '\\' {
+		return rewriteRule{}, fmt.Errorf("invalid rewrite delimiter: %c", delim)
+	}
+
+	parts := splitUnescaped(expr[1+len(string(delim)):], delim)
+	if len(parts) != 3 {
+		return rewriteRule{}, fmt.Errorf("invalid rewrite rule, expected field:s/pattern/replacement/flags: %s", s)
+	}
+	return newRewriteRule(field, parts[0], parts[1], parts[2])
+}
+
+// splitUnescaped splits s at each delim not preceded by a backslash, and
+// unescapes the escaped delimiters. Other escapes are kept for the regex.
+func splitUnescaped(s string, delim rune) []string {
+	var parts []string
+	var current strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == delim:
+			current.WriteRune(delim)
+			i++
+		case runes[i] == '\\' && i+1 < len(runes):
+			current.WriteRune(runes[i])
+			current.WriteRune(runes[i+1])
+			i++
+		case runes[i] == delim:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(runes[i])
+		}
+	}
+	return append(parts, current.String())
+}
+
+// replaceIn applies the rule to s
+func (r *rewriteRule) replaceIn(s string) string {
+	if r.all {
+		return r.pattern.ReplaceAllString(s, r.replace)
+	}
+
+	match := r.pattern.FindStringSubmatchIndex(s)
+	if match == nil {
+		return s
+	}
+	replaced := r.pattern.ExpandString(nil, r.replace, s, match)
+	return s[:match[0]] + string(replaced) + s[match[1]:]
+}
+
+// rewriteItems applies the rules in order to the fields of the items
+func rewriteItems(items []feed.Item, rules []rewriteRule) {
+	for i := range items {
+		item := &items[i]
+		for j := range rules {
+			rule := &rules[j]
+			switch rule.field {
+			case "title":
+				item.Title = rule.replaceIn(item.Title)
+			case "link":
+				item.Link = rule.replaceIn(item.Link)
+			case "description":
+				item.Summary = rule.replaceIn(item.Summary)
+				item.ContentHTML = rule.replaceIn(item.ContentHTML)
+				item.ContentText = rule.replaceIn(item.ContentText)
+				item.Description = rule.replaceIn(item.Description)
+			case "author":
+				item.Author = rule.replaceIn(item.Author)
+				for k := range item.Authors {
+					item.Authors[k].Name = rule.replaceIn(item.Authors[k].Name)
+				}
+			}
+		}
+	}
+}
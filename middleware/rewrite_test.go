@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+)
+
+func TestParseRewriteRule(t *testing.T) {
+	tests := []struct {
+		rule     string
+		input    string
+		expected string
+	}{
+		{`title:s/^\[Sponsored\] *//`, "[Sponsored] New phone", "New phone"},
+		{`title:s/sponsored: //i`, "SPONSORED: New phone", "New phone"},
+		{`title:s/o/0/`, "foo", "f0o"},
+		{`title:s/o/0/g`, "foo", "f00"},
+		{`link:s|utm_[^&#]*&?||g`, "https://example.com/a?utm_source=x&utm_medium=y&id=1", "https://example.com/a?id=1"},
+		{`link:s#//m\.#//www.#`, "https://m.example.com/a", "https://www.example.com/a"},
+		{`title:s/(\w+) (\w+)/$2 $1/`, "hello world", "world hello"},
+		{`title:s/a\/b/c/`, "a/b", "c"},
+	}
+	for _, tt := range tests {
+		rule, err := parseRewriteRule(tt.rule)
+		if err != nil {
+			t.Fatalf("parseRewriteRule(%q) failed: %v", tt.rule, err)
+		}
+		if result := rule.replaceIn(tt.input); result != tt.expected {
+			t.Errorf("%s on %q = %q, expected %q", tt.rule, tt.input, result, tt.expected)
+		}
+	}
+
+	for _, rule := range []string{
+		`title`,
+		`title:x/a/b/`,
+		`summary:s/a/b/`,
+		`title:s/a/b`,
+		`title:s/a/b/c/d`,
+		`title:s/a/b/x`,
+		`title:s/(/b/`,
+		`title:saab`,
+	} {
+		if _, err := parseRewriteRule(rule); err == nil {
+			t.Errorf("Expected error for %q", rule)
+		}
+	}
+}
+
+func TestParameter_Rewrite(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rewrite.json")
+	rules := `[
+		{"field": "title", "pattern": "^\\[Sponsored\\] *", "replace": ""},
+		{"route": "^/other", "field": "title", "pattern": "Phone", "replace": "Tablet"},
+		{"field": "author", "pattern": "@example\\.com$", "replace": "", "flags": "i"}
+	]`
+	if err := os.WriteFile(rulesFile, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config.C = &config.Config{RewriteRules: rulesFile}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Parameter(nil))
+	var data *feed.Data
+	router.GET("/test", func(c *gin.Context) {
+		data = &feed.Data{
+			Title: "Test",
+			Item: []feed.Item{
+				{Title: "[Sponsored] New Phone", Link: "https://m.example.com/1", Author: "alice@EXAMPLE.com", ContentHTML: "<p>Buy now</p>"},
+				{Title: "Review", Link: "https://m.example.com/2"},
+			},
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	// File rules run before query rules, and filters see the rewritten fields
+	query := url.Values{}
+	query.Add("rewrite", `link:s#//m\.#//www.#`)
+	query.Add("rewrite", `description:s/Buy now/Read more/`)
+	query.Set("filter_title", "^New")
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test?"+query.Encode(), nil)
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(data.Item) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(data.Item))
+	}
+	item := data.Item[0]
+	if item.Title != "New Phone" {
+		t.Errorf("Expected title rewritten by the file rule only, got %q", item.Title)
+	}
+	if item.Link != "https://www.example.com/1" {
+		t.Errorf("Expected desktop link, got %q", item.Link)
+	}
+	if item.Author != "alice" {
+		t.Errorf("Expected author rewritten, got %q", item.Author)
+	}
+	if item.ContentHTML != "<p>Read more</p>" {
+		t.Errorf("Expected content rewritten, got %q", item.ContentHTML)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/test?rewrite="+url.QueryEscape("title:s/(/x/"), nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid rule, got %d", w.Code)
+	}
+}
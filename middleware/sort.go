@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jean-jacket/grss/feed"
+)
+
+// sortKey is a field to sort items by and its direction
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+// sortFields maps the sortable fields to their default direction. Dates sort
+// newest first, text alphabetically.
+var sortFields = map[string]bool{
+	"date":    true,
+	"updated": true,
+	"title":   false,
+	"author":  false,
+}
+
+// dedupKeys returns the key that identifies duplicate items for each dedup
+// mode. Items with an empty key are never collapsed.
+var dedupKeys = map[string]func(item *feed.Item) string{
+	"guid": func(item *feed.Item) string {
+		return item.GUID
+	},
+	"link": func(item *feed.Item) string {
+		return strings.TrimSpace(item.Link)
+	},
+	"title": func(item *feed.Item) string {
+		return strings.ToLower(strings.Join(strings.Fields(item.Title), " "))
+	},
+}
+
+// parseSortKeys parses the sorted parameter: a comma-separated list of
+// fields, each optionally followed by :asc or :desc, e.g. "author,date:desc".
+// Later fields break ties between items that are equal on earlier ones. A
+// bare "asc" or "desc" sorts by date, as before fields were supported.
+func parseSortKeys(s string) ([]sortKey, error) {
+	switch s {
+	case "asc":
+		return []sortKey{{field: "date"}}, nil
+	case "desc":
+		return []sortKey{{field: "date", desc: true}}, nil
+	}
+
+	var keys []sortKey
+	for _, part := range strings.Split(s, ",") {
+		field, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+		desc, ok := sortFields[field]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field: %s", field)
+		}
+		switch direction {
+		case "":
+		case "asc":
+			desc = false
+		case "desc":
+			desc = true
+		default:
+			return nil, fmt.Errorf("unknown sort direction: %s", direction)
+		}
+		keys = append(keys, sortKey{field: field, desc: desc})
+	}
+	return keys, nil
+}
+
+// sortItems sorts items by the keys. The sort is stable, so items that are
+// equal on every key keep their feed order. Items missing a field, such as a
+// zero date, sort after the others in either direction.
+func sortItems(items []feed.Item, keys []sortKey) []feed.Item {
+	sorted := make([]feed.Item, len(items))
+	copy(sorted, items)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, key := range keys {
+			if cmp := compareItems(&sorted[i], &sorted[j], key); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+
+	return sorted
+}
+
+// compareItems compares two items on a sort key, returning a negative number
+// when a sorts first
+func compareItems(a, b *feed.Item, key sortKey) int {
+	switch key.field {
+	case "date":
+		return compareTimes(a.PubDate, b.PubDate, key.desc)
+	case "updated":
+		return compareTimes(a.Updated, b.Updated, key.desc)
+	case "title":
+		return compareStrings(a.Title, b.Title, key.desc)
+	default:
+		return compareStrings(firstAuthor(a), firstAuthor(b), key.desc)
+	}
+}
+
+// compareTimes compares two times, placing zero times last
+func compareTimes(a, b time.Time, desc bool) int {
+	switch {
+	case a.IsZero() || b.IsZero():
+		return boolToInt(a.IsZero()) - boolToInt(b.IsZero())
+	case desc:
+		return b.Compare(a)
+	default:
+		return a.Compare(b)
+	}
+}
+
+// compareStrings compares two strings regardless of case, placing empty
+// strings last
+func compareStrings(a, b string, desc bool) int {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	switch {
+	case a == "" || b == "":
+		return boolToInt(a == "") - boolToInt(b == "")
+	case desc:
+		return strings.Compare(b, a)
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// firstAuthor returns the name of the first author of an item
+func firstAuthor(item *feed.Item) string {
+	if authors := item.AllAuthors(); len(authors) > 0 {
+		return authors[0].DisplayName()
+	}
+	return ""
+}
+
+// boolToInt converts a bool to 1 or 0
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// dedupItems keeps the first of the items that share a key
+func dedupItems(items []feed.Item, key func(item *feed.Item) string) []feed.Item {
+	seen := map[string]bool{}
+	deduped := []feed.Item{}
+	for i := range items {
+		k := key(&items[i])
+		if k != "" {
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+		}
+		deduped = append(deduped, items[i])
+	}
+
+	return deduped
+}
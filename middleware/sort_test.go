@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
+	"github.com/jean-jacket/grss/feed"
+)
+
+func TestParseSortKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []sortKey
+	}{
+		{"asc", []sortKey{{field: "date"}}},
+		{"desc", []sortKey{{field: "date", desc: true}}},
+		{"date", []sortKey{{field: "date", desc: true}}},
+		{"title", []sortKey{{field: "title"}}},
+		{"author,updated:asc", []sortKey{{field: "author"}, {field: "updated"}}},
+		{"title:desc, date", []sortKey{{field: "title", desc: true}, {field: "date", desc: true}}},
+	}
+	for _, tt := range tests {
+		keys, err := parseSortKeys(tt.input)
+		if err != nil {
+			t.Fatalf("parseSortKeys(%q) failed: %v", tt.input, err)
+		}
+		if len(keys) != len(tt.expected) {
+			t.Fatalf("parseSortKeys(%q) = %+v, expected %+v", tt.input, keys, tt.expected)
+		}
+		for i := range keys {
+			if keys[i] != tt.expected[i] {
+				t.Errorf("parseSortKeys(%q) = %+v, expected %+v", tt.input, keys, tt.expected)
+			}
+		}
+	}
+
+	for _, input := range []string{"size", "title:up", "date,"} {
+		if _, err := parseSortKeys(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+func TestSortItems_Fields(t *testing.T) {
+	now := time.Now()
+	items := []feed.Item{
+		{Title: "beta", Author: "Bob", PubDate: now},
+		{Title: "No date", Author: "alice"},
+		{Title: "Alpha", Author: "Bob", PubDate: now.Add(-time.Hour)},
+		{Title: "gamma", Authors: []feed.Person{{Name: "Alice"}}, PubDate: now.Add(time.Hour)},
+	}
+
+	titles := func(items []feed.Item) string {
+		var result []string
+		for _, item := range items {
+			result = append(result, item.Title)
+		}
+		return strings.Join(result, ",")
+	}
+
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		// Items without a date sort last in both directions
+		{"date:asc", "Alpha,beta,gamma,No date"},
+		{"date:desc", "gamma,beta,Alpha,No date"},
+		{"title", "Alpha,beta,gamma,No date"},
+		// Equal authors keep their feed order unless a secondary key is given
+		{"author", "No date,gamma,beta,Alpha"},
+		{"author,date:asc", "gamma,No date,Alpha,beta"},
+	}
+	for _, tt := range tests {
+		keys, _ := parseSortKeys(tt.keys)
+		if result := titles(sortItems(items, keys)); result != tt.expected {
+			t.Errorf("sorted=%s: got %s, expected %s", tt.keys, result, tt.expected)
+		}
+	}
+}
+
+func TestDedupItems(t *testing.T) {
+	items := []feed.Item{
+		{Title: "First", GUID: "1", Link: "https://example.com/a"},
+		{Title: "first ", GUID: "2", Link: "https://example.com/a"},
+		{Title: "Second", GUID: "1", Link: "https://example.com/b"},
+		{Title: "Untitled"},
+		{Title: "Untitled"},
+	}
+
+	tests := []struct {
+		mode     string
+		expected int
+	}{
+		{"guid", 4},
+		{"link", 4},
+		{"title", 3},
+	}
+	for _, tt := range tests {
+		if deduped := dedupItems(items, dedupKeys[tt.mode]); len(deduped) != tt.expected {
+			t.Errorf("dedup=%s: expected %d items, got %d", tt.mode, tt.expected, len(deduped))
+		}
+	}
+}
+
+func TestParameter_OffsetAndDedup(t *testing.T) {
+	config.C = &config.Config{}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Parameter(nil))
+	var data *feed.Data
+	router.GET("/test", func(c *gin.Context) {
+		data = &feed.Data{
+			Title: "Test",
+			Item: []feed.Item{
+				{Title: "One", Link: "https://example.com/1"},
+				{Title: "One again", Link: "https://example.com/1"},
+				{Title: "Two", Link: "https://example.com/2"},
+				{Title: "Three", Link: "https://example.com/3"},
+				{Title: "Four", Link: "https://example.com/4"},
+			},
+		}
+		c.Set(ContextKeyData, data)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test?dedup=link&offset=1&limit=2", nil)
+	router.ServeHTTP(w, req)
+	if len(data.Item) != 2 || data.Item[0].Title != "Two" || data.Item[1].Title != "Three" {
+		t.Errorf("Expected Two and Three, got %+v", data.Item)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/test?offset=10", nil)
+	router.ServeHTTP(w, req)
+	if len(data.Item) != 0 {
+		t.Errorf("Expected no items past the end, got %d", len(data.Item))
+	}
+
+	for _, query := range []string{"dedup=content", "sorted=size"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/test?"+query, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
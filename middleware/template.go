@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
 	"github.com/jean-jacket/grss/feed"
 	"github.com/jean-jacket/grss/utils"
 )
@@ -11,12 +12,27 @@ import (
 const (
 	// ContextKeyData is the key for storing feed data in context
 	ContextKeyData = "feed_data"
+
+	// StylesheetPath is where the XSLT stylesheet for RSS and Atom output is served
+	StylesheetPath = "/feed.xsl"
 )
 
-// Template middleware converts Data object to feed format (RSS/Atom/JSON)
+// Template middleware converts Data object to the negotiated feed format
 func Template() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Execute handler first
+		// Reject unknown formats before running the handler
+		format, err := negotiateFormat(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": gin.H{
+					"message": err.Error(),
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		// Execute handler
 		c.Next()
 
 		// Check if data was set by handler
@@ -39,38 +55,16 @@ func Template() gin.HandlerFunc {
 			return
 		}
 
-		// Get format from query parameter
-		format := c.DefaultQuery("format", "rss")
-
 		// Get current URL
-		currentURL := c.Request.URL.String()
-		if c.Request.Host != "" {
-			scheme := "http"
-			if c.Request.TLS != nil {
-				scheme = "https"
-			}
-			currentURL = scheme + "://" + c.Request.Host + c.Request.URL.String()
-		}
-
-		// Generate feed based on format
-		var output string
-		var err error
-		var contentType string
+		currentURL := baseURL(c) + c.Request.URL.String()
 
-		switch format {
-		case "atom":
-			output, err = feed.GenerateAtom(data, currentURL)
-			contentType = "application/atom+xml; charset=utf-8"
-		case "json":
-			output, err = feed.GenerateJSON(data, currentURL)
-			contentType = "application/json; charset=utf-8"
-		case "rss":
-			fallthrough
-		default:
-			output, err = feed.GenerateRSS(data, currentURL)
-			contentType = "application/rss+xml; charset=utf-8"
+		// Reference the XSLT stylesheet so browsers render XML feeds as HTML
+		if format.Stylesheet && config.C.FeedStylesheet {
+			data.Stylesheet = StylesheetPath
 		}
 
+		// Generate feed in the negotiated format
+		output, err := format.Generate(data, currentURL)
 		if err != nil {
 			utils.LogError("Failed to generate feed: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -83,7 +77,43 @@ func Template() gin.HandlerFunc {
 		}
 
 		// Set content type and return feed
-		c.Header("Content-Type", contentType)
+		c.Header("Content-Type", contentType(c, format))
+		c.Header("Vary", "Accept")
+		setFormatHeaders(c, format)
 		c.String(http.StatusOK, output)
 	}
 }
+
+// baseURL returns the scheme and host the request was made to, or an empty
+// string when the host is unknown
+func baseURL(c *gin.Context) string {
+	if c.Request.Host == "" {
+		return ""
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// contentType returns the Content-Type of a rendered feed. Browsers only apply
+// XSLT stylesheets to generic XML documents, so they get application/xml
+// instead of the feed media type when the stylesheet is enabled.
+func contentType(c *gin.Context, format *feed.Format) string {
+	if format.Stylesheet && config.C.FeedStylesheet && acceptsHTML(c.GetHeader("Accept")) {
+		return "application/xml; charset=utf-8"
+	}
+	return format.ContentType
+}
+
+// setFormatHeaders sends the headers of a format, and a restrictive
+// Content-Security-Policy with feeds that browsers render with the stylesheet
+func setFormatHeaders(c *gin.Context, format *feed.Format) {
+	for name, value := range format.Headers {
+		c.Header(name, value)
+	}
+	if format.Stylesheet && config.C.FeedStylesheet {
+		c.Header("Content-Security-Policy", feed.StylesheetContentSecurityPolicy)
+	}
+}
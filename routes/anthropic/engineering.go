@@ -24,6 +24,7 @@ var EngineeringRoute = registry.Route{
 	Parameters:  map[string]interface{}{},
 	Description: "Get latest posts from Anthropic's engineering blog",
 	Handler:     engineeringHandler,
+	Timezone:    "America/Los_Angeles",
 }
 
 func engineeringHandler(c *gin.Context) (*feed.Data, error) {
@@ -63,7 +64,7 @@ func engineeringHandler(c *gin.Context) (*feed.Data, error) {
 			text := strings.TrimSpace(span.Text())
 			return strings.Contains(strings.ToLower(text), "featured")
 		}).Length() > 0 {
-			item := parseFeaturedItem(s, htmlContent)
+			item := parseFeaturedItem(s, htmlContent, registry.Location(c))
 			if item.Title != "" {
 				feedData.Item = append(feedData.Item, item)
 			}
@@ -73,7 +74,7 @@ func engineeringHandler(c *gin.Context) (*feed.Data, error) {
 	// Parse regular engineering items
 	// These have h3 with class "display-sans-s bold" and a div with class "detail-m ArticleList_date__2VTRg"
 	doc.Find("h3.display-sans-s.bold").Each(func(i int, s *goquery.Selection) {
-		item := parseEngineeringItem(s)
+		item := parseEngineeringItem(s, registry.Location(c))
 		if item.Title != "" {
 			feedData.Item = append(feedData.Item, item)
 		}
@@ -92,7 +93,7 @@ func engineeringHandler(c *gin.Context) (*feed.Data, error) {
 	return feedData, nil
 }
 
-func parseFeaturedItem(s *goquery.Selection, htmlContent string) feed.Item {
+func parseFeaturedItem(s *goquery.Selection, htmlContent string, loc *time.Location) feed.Item {
 	var item feed.Item
 
 	// Get link href
@@ -132,14 +133,14 @@ func parseFeaturedItem(s *goquery.Selection, htmlContent string) feed.Item {
 	// Try to extract date from embedded JS
 	// Look for the slug in the HTML and walk back to find publishedOn
 	if slug != "" {
-		pubDate := extractDateFromJS(htmlContent, slug)
+		pubDate := extractDateFromJS(htmlContent, slug, loc)
 		item.PubDate = pubDate
 	}
 
 	return item
 }
 
-func parseEngineeringItem(h3 *goquery.Selection) feed.Item {
+func parseEngineeringItem(h3 *goquery.Selection, loc *time.Location) feed.Item {
 	var item feed.Item
 
 	// Get title
@@ -173,13 +174,13 @@ func parseEngineeringItem(h3 *goquery.Selection) feed.Item {
 
 	if dateDiv.Length() > 0 {
 		dateStr := strings.TrimSpace(dateDiv.Text())
-		item.PubDate = parseEngineeringDate(dateStr)
+		item.PubDate = parseEngineeringDate(dateStr, loc)
 	}
 
 	return item
 }
 
-func extractDateFromJS(htmlContent, slug string) time.Time {
+func extractDateFromJS(htmlContent, slug string, loc *time.Location) time.Time {
 	// Escape special regex characters in slug
 	escapedSlug := regexp.QuoteMeta(slug)
 
@@ -215,7 +216,7 @@ func extractDateFromJS(htmlContent, slug string) time.Time {
 
 	if len(dateMatches) > 1 {
 		// Parse the date
-		t, err := time.Parse("2006-01-02", dateMatches[1])
+		t, err := time.ParseInLocation("2006-01-02", dateMatches[1], loc)
 		if err == nil {
 			return t
 		}
@@ -228,7 +229,7 @@ func extractDateFromJS(htmlContent, slug string) time.Time {
 
 	if len(dateMatches) > 1 {
 		// Parse the date
-		t, err := time.Parse("2006-01-02", dateMatches[1])
+		t, err := time.ParseInLocation("2006-01-02", dateMatches[1], loc)
 		if err == nil {
 			return t
 		}
@@ -237,7 +238,7 @@ func extractDateFromJS(htmlContent, slug string) time.Time {
 	return time.Time{}
 }
 
-func parseEngineeringDate(dateStr string) time.Time {
+func parseEngineeringDate(dateStr string, loc *time.Location) time.Time {
 	dateStr = strings.TrimSpace(dateStr)
 	if dateStr == "" {
 		return time.Time{}
@@ -254,7 +255,7 @@ func parseEngineeringDate(dateStr string) time.Time {
 	}
 
 	for _, format := range formats {
-		if t, err := time.Parse(format, dateStr); err == nil {
+		if t, err := time.ParseInLocation(format, dateStr, loc); err == nil {
 			return t
 		}
 	}
@@ -23,6 +23,7 @@ var NewsRoute = registry.Route{
 	Parameters:  map[string]interface{}{},
 	Description: "Get latest news and announcements from Anthropic",
 	Handler:     newsHandler,
+	Timezone:    "America/Los_Angeles",
 }
 
 func newsHandler(c *gin.Context) (*feed.Data, error) {
@@ -54,7 +55,7 @@ func newsHandler(c *gin.Context) (*feed.Data, error) {
 
 	// Parse spotlight items
 	doc.Find("a[class*='CardSpotlight_spotlightCard']").Each(func(i int, s *goquery.Selection) {
-		item := parseNewsItem(s, "spotlight")
+		item := parseNewsItem(s, "spotlight", registry.Location(c))
 		if item.Title != "" {
 			feedData.Item = append(feedData.Item, item)
 		}
@@ -62,7 +63,7 @@ func newsHandler(c *gin.Context) (*feed.Data, error) {
 
 	// Parse regular news items
 	doc.Find("a[class*='Card_linkRoot']").Each(func(i int, s *goquery.Selection) {
-		item := parseNewsItem(s, "regular")
+		item := parseNewsItem(s, "regular", registry.Location(c))
 		if item.Title != "" {
 			feedData.Item = append(feedData.Item, item)
 		}
@@ -81,7 +82,7 @@ func newsHandler(c *gin.Context) (*feed.Data, error) {
 	return feedData, nil
 }
 
-func parseNewsItem(s *goquery.Selection, itemType string) feed.Item {
+func parseNewsItem(s *goquery.Selection, itemType string, loc *time.Location) feed.Item {
 	var item feed.Item
 
 	// Get link href
@@ -130,13 +131,13 @@ func parseNewsItem(s *goquery.Selection, itemType string) feed.Item {
 	item.Category = []string{category}
 
 	// Parse date
-	pubDate := parseDate(dateStr)
+	pubDate := parseDate(dateStr, loc)
 	item.PubDate = pubDate
 
 	return item
 }
 
-func parseDate(dateStr string) time.Time {
+func parseDate(dateStr string, loc *time.Location) time.Time {
 	dateStr = strings.TrimSpace(dateStr)
 	if dateStr == "" {
 		return time.Time{}
@@ -152,7 +153,7 @@ func parseDate(dateStr string) time.Time {
 	}
 
 	for _, format := range formats {
-		if t, err := time.Parse(format, dateStr); err == nil {
+		if t, err := time.ParseInLocation(format, dateStr, loc); err == nil {
 			return t
 		}
 	}
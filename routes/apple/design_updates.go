@@ -25,6 +25,7 @@ var DesignUpdatesRoute = registry.Route{
 	Parameters:  map[string]interface{}{},
 	Description: "Get latest design updates from Apple Developer Design",
 	Handler:     designUpdatesHandler,
+	Timezone:    "America/Los_Angeles",
 }
 
 func designUpdatesHandler(c *gin.Context) (*feed.Data, error) {
@@ -83,17 +84,17 @@ func designUpdatesHandler(c *gin.Context) (*feed.Data, error) {
 			description := strings.TrimSpace(topicItem.Find("span.description").Text())
 
 			// Parse date
-			pubDate := parseDate(dateStr)
+			pubDate := parseDate(dateStr, registry.Location(c))
 
 			// Generate GUID from title + description + date
 			guid := generateGUID(title, description, dateStr)
 
 			item := feed.Item{
-				Title:       title,
-				Link:        link,
-				Description: description,
-				PubDate:     pubDate,
-				GUID:        guid,
+				Title:   title,
+				Link:    link,
+				Summary: description,
+				PubDate: pubDate,
+				GUID:    guid,
 			}
 
 			feedData.Item = append(feedData.Item, item)
@@ -115,10 +116,11 @@ func generateGUID(title, description, date string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// parseDate attempts to parse various date formats from the page
-func parseDate(dateStr string) time.Time {
+// parseDate attempts to parse various date formats from the page in loc,
+// returning the zero time when no format matches
+func parseDate(dateStr string, loc *time.Location) time.Time {
 	if dateStr == "" {
-		return time.Now()
+		return time.Time{}
 	}
 
 	// Try common formats
@@ -137,10 +139,10 @@ func parseDate(dateStr string) time.Time {
 	}
 
 	for _, format := range formats {
-		if t, err := time.Parse(format, dateStr); err == nil {
+		if t, err := time.ParseInLocation(format, dateStr, loc); err == nil {
 			return t
 		}
 	}
 
-	return time.Now()
+	return time.Time{}
 }
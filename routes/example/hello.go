@@ -29,19 +29,19 @@ func helloHandler(c *gin.Context) (*feed.Data, error) {
 		PubDate:     now,
 		Item: []feed.Item{
 			{
-				Title:       "Hello, GRSS!",
-				Link:        "https://example.com/hello",
-				Description: "Welcome to the GRSS feed aggregation system",
-				PubDate:     now,
-				Author:      "GRSS Team",
-				Category:    []string{"demo", "example"},
+				Title:    "Hello, GRSS!",
+				Link:     "https://example.com/hello",
+				Summary:  "Welcome to the GRSS feed aggregation system",
+				PubDate:  now,
+				Author:   "GRSS Team",
+				Category: []string{"demo", "example"},
 			},
 			{
-				Title:       "Second Item",
-				Link:        "https://example.com/second",
-				Description: "This demonstrates multiple items in a feed",
-				PubDate:     now.Add(-1 * time.Hour),
-				Author:      "GRSS Team",
+				Title:   "Second Item",
+				Link:    "https://example.com/second",
+				Summary: "This demonstrates multiple items in a feed",
+				PubDate: now.Add(-1 * time.Hour),
+				Author:  "GRSS Team",
 			},
 		},
 	}, nil
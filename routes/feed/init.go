@@ -0,0 +1,9 @@
+package feed
+
+import "github.com/jean-jacket/grss/routes/registry"
+
+// init registers all Feed routes
+func init() {
+	registry.RegisterNamespace("feed", Namespace)
+	registry.RegisterRoute("feed", ProxyRoute)
+}
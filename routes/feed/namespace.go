@@ -0,0 +1,12 @@
+package feed
+
+import "github.com/jean-jacket/grss/routes/registry"
+
+// Namespace defines the Feed namespace
+var Namespace = &registry.Namespace{
+	Name:        "Feed",
+	URL:         "https://github.com/jean-jacket/grss",
+	Description: "Generic routes for existing RSS, Atom and JSON feeds",
+	Lang:        "en",
+	Categories:  []string{"utility"},
+}
@@ -0,0 +1,75 @@
+package feed
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/client"
+	"github.com/jean-jacket/grss/config"
+	grssfeed "github.com/jean-jacket/grss/feed"
+	"github.com/jean-jacket/grss/routes/registry"
+)
+
+// ProxyRoute defines the feed proxy route
+var ProxyRoute = registry.Route{
+	Path:        "/proxy",
+	Name:        "Feed Proxy",
+	Maintainers: []string{"grss"},
+	Example:     "/feed/proxy?url=https://go.dev/blog/feed.atom&filter=release",
+	Description: "Re-publish any RSS, Atom or JSON feed so that the query parameters (filter, filterout, limit, sorted, format) can be applied to it",
+	Parameters: map[string]interface{}{
+		"url": "URL of the upstream feed (http or https)",
+	},
+	Features: &registry.Features{
+		RequireConfig: []registry.ConfigRequirement{
+			{Name: "ACCESS_KEY", Optional: false},
+		},
+	},
+	QueryParameters: []string{"url"},
+	// A code only grants the feed it was made for, not any URL
+	CodeQueryParameters: []string{"url"},
+	Handler:             proxyHandler,
+}
+
+func proxyHandler(c *gin.Context) (*grssfeed.Data, error) {
+	// Without an access key anyone could make the server fetch any URL,
+	// including internal addresses, like the image proxy
+	if config.C.AccessKey == "" {
+		return nil, fmt.Errorf("feed proxy requires ACCESS_KEY")
+	}
+
+	feedURL := c.Query("url")
+	if feedURL == "" {
+		return nil, fmt.Errorf("missing url parameter")
+	}
+
+	// Only allow absolute HTTP(S) URLs
+	u, err := url.Parse(feedURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid feed URL: %s", feedURL)
+	}
+
+	// Fetch upstream feed
+	httpClient := client.New(config.C)
+	headers := map[string]string{
+		"Accept": "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8",
+	}
+
+	body, err := httpClient.Get(feedURL, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+
+	// Parse feed
+	data, err := grssfeed.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	if data.Link == "" {
+		data.Link = feedURL
+	}
+
+	return data, nil
+}
@@ -0,0 +1,23 @@
+package feed
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/config"
+)
+
+func TestProxyHandler_RequiresAccessKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config.C = &config.Config{}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/feed/proxy?url=http://127.0.0.1/feed.xml", nil)
+
+	_, err := proxyHandler(c)
+	if err == nil || !strings.Contains(err.Error(), "ACCESS_KEY") {
+		t.Errorf("Expected the proxy to require ACCESS_KEY, got %v", err)
+	}
+}
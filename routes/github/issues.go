@@ -22,8 +22,9 @@ var IssuesRoute = registry.Route{
 		"user": "GitHub username",
 		"repo": "Repository name",
 	},
-	Description: "Get latest issues from a GitHub repository",
-	Handler:     issuesHandler,
+	Description:     "Get latest issues from a GitHub repository",
+	QueryParameters: []string{"state"},
+	Handler:         issuesHandler,
 }
 
 type githubIssue struct {
@@ -88,7 +89,7 @@ func issuesHandler(c *gin.Context) (*feed.Data, error) {
 		item := feed.Item{
 			Title:       fmt.Sprintf("#%d: %s", issue.Number, issue.Title),
 			Link:        issue.HTMLURL,
-			Description: issue.Body,
+			ContentText: issue.Body, // Markdown source, not HTML
 			PubDate:     issue.CreatedAt,
 			Author:      issue.User.Login,
 			Category:    labels,
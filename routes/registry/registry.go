@@ -1,10 +1,17 @@
 package registry
 
 import (
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/jean-jacket/grss/feed"
+	"github.com/jean-jacket/grss/utils"
 )
 
+// ContextKeyLocation is the key for storing the route timezone in context
+const ContextKeyLocation = "route_location"
+
 // Route defines a route handler and metadata
 type Route struct {
 	// Route configuration
@@ -21,6 +28,44 @@ type Route struct {
 	Description string
 	Categories  []string
 	Features    *Features
+
+	// QueryParameters lists the query parameters the handler reads. They
+	// affect the output, so they are part of the route cache key.
+	QueryParameters []string
+
+	// CodeQueryParameters lists the query parameters covered by access
+	// codes, for routes where a code must only grant the values it was
+	// made for, such as the URL a proxy fetches.
+	CodeQueryParameters []string
+
+	// Timezone is the IANA name of the timezone the source publishes dates
+	// in, e.g. "America/Los_Angeles". Handlers parse dates that carry no
+	// zone in it, see Location.
+	Timezone string
+}
+
+// Location returns the timezone of the route, or UTC when none is set or it
+// cannot be loaded
+func (r Route) Location() *time.Location {
+	if r.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		utils.LogError("Invalid timezone %q for route %s: %v", r.Timezone, r.Path, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// Location returns the timezone of the route serving the request, or UTC
+func Location(c *gin.Context) *time.Location {
+	if loc, ok := c.Get(ContextKeyLocation); ok {
+		if loc, ok := loc.(*time.Location); ok {
+			return loc
+		}
+	}
+	return time.UTC
 }
 
 // RouteHandler is the function signature for route handlers
@@ -87,18 +132,21 @@ func (r *Registry) MountRoutes(router *gin.Engine) {
 	for namespaceName, namespace := range r.namespaces {
 		for _, route := range namespace.Routes {
 			path := "/" + namespaceName + route.Path
-			router.GET(path, wrapHandler(route.Handler))
+			router.GET(path, wrapHandler(route.Handler, route.Location()))
 		}
 	}
 }
 
 // wrapHandler wraps a RouteHandler to work with Gin
-func wrapHandler(handler RouteHandler) gin.HandlerFunc {
+func wrapHandler(handler RouteHandler, loc *time.Location) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Execute handler
+		// Execute handler in the route timezone
+		c.Set(ContextKeyLocation, loc)
 		data, err := handler(c)
 		if err != nil {
-			// Error handling - return error response
+			// Error handling - return error response, recording the error
+			// for the requests waiting on this one
+			_ = c.Error(err)
 			c.JSON(500, gin.H{
 				"error": gin.H{
 					"message": err.Error(),
@@ -113,6 +161,23 @@ func wrapHandler(handler RouteHandler) gin.HandlerFunc {
 	}
 }
 
+// LookupRoute returns the route mounted at a full path pattern, such as
+// "/github/issue/:user/:repo"
+func (r *Registry) LookupRoute(fullPath string) (Route, bool) {
+	for namespaceName, namespace := range r.namespaces {
+		prefix := "/" + namespaceName
+		if !strings.HasPrefix(fullPath, prefix) {
+			continue
+		}
+		for _, route := range namespace.Routes {
+			if prefix+route.Path == fullPath {
+				return route, true
+			}
+		}
+	}
+	return Route{}, false
+}
+
 // GetNamespaces returns all registered namespaces
 func (r *Registry) GetNamespaces() map[string]*Namespace {
 	return r.namespaces
@@ -159,6 +224,12 @@ type RouteInfo struct {
 	Route     Route
 }
 
+// LookupRoute returns the route mounted at a full path pattern in the
+// default registry
+func LookupRoute(fullPath string) (Route, bool) {
+	return DefaultRegistry.LookupRoute(fullPath)
+}
+
 // GetAllRoutes returns all routes from the default registry
 func GetAllRoutes() map[string]RouteInfo {
 	return DefaultRegistry.GetAllRoutes()
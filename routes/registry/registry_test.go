@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jean-jacket/grss/feed"
@@ -102,7 +103,7 @@ func TestWrapHandler_Success(t *testing.T) {
 		}, nil
 	}
 
-	wrapped := wrapHandler(handler)
+	wrapped := wrapHandler(handler, time.UTC)
 
 	// Create test context
 	w := httptest.NewRecorder()
@@ -133,7 +134,7 @@ func TestWrapHandler_Error(t *testing.T) {
 		return nil, errors.New("test error")
 	}
 
-	wrapped := wrapHandler(handler)
+	wrapped := wrapHandler(handler, time.UTC)
 
 	// Create test context
 	w := httptest.NewRecorder()
@@ -147,6 +148,29 @@ func TestWrapHandler_Error(t *testing.T) {
 	}
 }
 
+func TestWrapHandler_Location(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	route := Route{Path: "/tz", Timezone: "Asia/Tokyo"}
+	var loc *time.Location
+	handler := func(c *gin.Context) (*feed.Data, error) {
+		loc = Location(c)
+		return &feed.Data{Title: "Timezone"}, nil
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	wrapHandler(handler, route.Location())(c)
+
+	if loc == nil || loc.String() != "Asia/Tokyo" {
+		t.Errorf("Expected handler to run in Asia/Tokyo, got %v", loc)
+	}
+
+	if loc := (Route{Path: "/bad", Timezone: "Mars/Olympus"}).Location(); loc != time.UTC {
+		t.Errorf("Expected UTC for an invalid timezone, got %v", loc)
+	}
+}
+
 func TestDefaultRegistry(t *testing.T) {
 	// Test that default registry exists and works
 	route := Route{
@@ -163,6 +187,25 @@ func TestDefaultRegistry(t *testing.T) {
 	}
 }
 
+func TestLookupRoute(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterNamespace("test", &Namespace{
+		Name: "Test",
+		Routes: []Route{
+			{Path: "/issues/:user", Name: "Issues", QueryParameters: []string{"state"}, Handler: testRouteHandler},
+		},
+	})
+
+	route, ok := registry.LookupRoute("/test/issues/:user")
+	if !ok || route.Name != "Issues" || len(route.QueryParameters) != 1 {
+		t.Errorf("Expected the Issues route, got %+v (found %v)", route, ok)
+	}
+
+	if _, ok := registry.LookupRoute("/test/pulls/:user"); ok {
+		t.Error("Expected no route for an unknown path")
+	}
+}
+
 // Helper function for tests
 func testRouteHandler(c *gin.Context) (*feed.Data, error) {
 	return &feed.Data{
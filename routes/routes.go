@@ -10,6 +10,7 @@ import (
 	_ "github.com/jean-jacket/grss/routes/anthropic"
 	_ "github.com/jean-jacket/grss/routes/apple"
 	_ "github.com/jean-jacket/grss/routes/example"
+	_ "github.com/jean-jacket/grss/routes/feed"
 	_ "github.com/jean-jacket/grss/routes/github"
 	_ "github.com/jean-jacket/grss/routes/youtube"
 )
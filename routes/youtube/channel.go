@@ -18,7 +18,8 @@ var ChannelRoute = registry.Route{
 	Parameters: map[string]interface{}{
 		"id": "YouTube channel ID (must start with UC)",
 	},
-	Handler: channelHandler,
+	QueryParameters: []string{"embed", "filterShorts"},
+	Handler:         channelHandler,
 }
 
 func channelHandler(c *gin.Context) (*feed.Data, error) {
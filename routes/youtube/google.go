@@ -348,26 +348,14 @@ func (g *GoogleAPI) getDataByChannelID(channelID string, embed bool, filterShort
 
 		feedItem := feed.Item{
 			Title:       title,
-			Description: renderedDesc,
+			ContentHTML: renderedDesc,
 			Link:        fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
 			PubDate:     pubDate,
 			Author:      author,
 		}
 
-		// Add media enclosure
-		if img != nil {
-			feedItem.Media = &feed.Media{
-				Thumbnail: &feed.MediaThumbnail{
-					URL: img.URL,
-				},
-			}
-		}
-
-		// Add video URL as enclosure
-		if durationSeconds > 0 {
-			feedItem.EnclosureURL = getVideoURL(videoID)
-			feedItem.EnclosureType = "text/html"
-		}
+		// Add Media RSS metadata, with the embedded player of the video
+		feedItem.Media = buildMedia(videoID, title, author, img, durationSeconds)
 
 		feedItems = append(feedItems, feedItem)
 	}
@@ -641,26 +629,14 @@ func (g *GoogleAPI) getDataByPlaylistID(playlistID string, embed bool) (*feed.Da
 
 		feedItem := feed.Item{
 			Title:       title,
-			Description: renderedDesc,
+			ContentHTML: renderedDesc,
 			Link:        fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
 			PubDate:     pubDate,
 			Author:      author,
 		}
 
-		// Add media enclosure
-		if img != nil {
-			feedItem.Media = &feed.Media{
-				Thumbnail: &feed.MediaThumbnail{
-					URL: img.URL,
-				},
-			}
-		}
-
-		// Add video URL as enclosure
-		if durationSeconds > 0 {
-			feedItem.EnclosureURL = getVideoURL(videoID)
-			feedItem.EnclosureType = "text/html"
-		}
+		// Add Media RSS metadata, with the embedded player of the video
+		feedItem.Media = buildMedia(videoID, title, author, img, durationSeconds)
 
 		feedItems = append(feedItems, feedItem)
 	}
@@ -16,7 +16,8 @@ var PlaylistRoute = registry.Route{
 	Parameters: map[string]interface{}{
 		"id": "YouTube playlist ID",
 	},
-	Handler: playlistHandler,
+	QueryParameters: []string{"embed"},
+	Handler:         playlistHandler,
 }
 
 func playlistHandler(c *gin.Context) (*feed.Data, error) {
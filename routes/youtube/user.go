@@ -16,7 +16,8 @@ var UserRoute = registry.Route{
 	Parameters: map[string]interface{}{
 		"username": "YouTube username or handle (with @ prefix for handles)",
 	},
-	Handler: userHandler,
+	QueryParameters: []string{"embed", "filterShorts"},
+	Handler:         userHandler,
 }
 
 func userHandler(c *gin.Context) (*feed.Data, error) {
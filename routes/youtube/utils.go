@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jean-jacket/grss/feed"
 )
 
 // parseBoolParam parses a boolean query parameter with a default value
@@ -90,6 +91,34 @@ func getVideoURL(videoID string) string {
 	return fmt.Sprintf("https://www.youtube-nocookie.com/embed/%s?controls=1&autoplay=1&mute=0", videoID)
 }
 
+// buildMedia builds the Media RSS metadata for a video. The embed page is a
+// player, not a media file, so it is not an enclosure or media:content;
+// videos without a duration, such as upcoming streams, have none.
+func buildMedia(videoID, title, author string, img *Thumbnail, durationSeconds int) *feed.Media {
+	media := &feed.Media{
+		Title:  title,
+		Credit: author,
+	}
+
+	if durationSeconds > 0 {
+		media.Player = &feed.MediaPlayer{
+			URL:    getVideoURL(videoID),
+			Width:  640,
+			Height: 360,
+		}
+	}
+
+	if img != nil {
+		media.Thumbnail = &feed.MediaThumbnail{
+			URL:    img.URL,
+			Width:  img.Width,
+			Height: img.Height,
+		}
+	}
+
+	return media
+}
+
 // isYouTubeChannelID validates if a string is a valid YouTube channel ID
 // Format: UC followed by 21 alphanumeric/hyphen characters, ending with A, Q, g, or w
 func isYouTubeChannelID(id string) bool {
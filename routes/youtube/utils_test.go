@@ -145,3 +145,18 @@ func TestParseDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildMedia(t *testing.T) {
+	media := buildMedia("abc123", "Title", "Author", nil, 125)
+	if media.Content != nil {
+		t.Errorf("Expected no media content for the embed page, got %+v", media.Content)
+	}
+	if media.Player == nil || media.Player.URL != getVideoURL("abc123") {
+		t.Errorf("Expected the embed page as the player, got %+v", media.Player)
+	}
+
+	media = buildMedia("abc123", "Title", "Author", nil, 0)
+	if media.Player != nil {
+		t.Errorf("Expected no player without a duration, got %+v", media.Player)
+	}
+}
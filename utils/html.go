@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blockElements lists the HTML elements that start a new paragraph in plain text
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"figure": true, "footer": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "header": true, "hr": true,
+	"li": true, "main": true, "nav": true, "ol": true, "p": true,
+	"pre": true, "section": true, "table": true, "tr": true, "ul": true,
+}
+
+// skippedElements lists the HTML elements whose content is not text
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+}
+
+var (
+	horizontalSpace = regexp.MustCompile(`[ \t\f\r\v\p{Zs}]+`)
+	blankLines      = regexp.MustCompile(`\n{3,}`)
+)
+
+// StripHTML converts an HTML fragment to plain text. Entities are decoded,
+// script and style contents are dropped, <br> becomes a line break and
+// block-level elements are separated by blank lines.
+func StripHTML(s string) string {
+	if !strings.ContainsAny(s, "<&") {
+		return CollapseWhitespace(s)
+	}
+
+	var builder strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	skipDepth := 0
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		token := tokenizer.Token()
+		switch tokenType {
+		case html.TextToken:
+			if skipDepth == 0 {
+				builder.WriteString(token.Data)
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if skippedElements[token.Data] && tokenType == html.StartTagToken {
+				skipDepth++
+			} else if token.Data == "br" {
+				builder.WriteString("\n")
+			} else if blockElements[token.Data] {
+				builder.WriteString("\n\n")
+			}
+		case html.EndTagToken:
+			if skippedElements[token.Data] && skipDepth > 0 {
+				skipDepth--
+			} else if blockElements[token.Data] {
+				builder.WriteString("\n\n")
+			}
+		}
+	}
+
+	return CollapseWhitespace(builder.String())
+}
+
+// CollapseWhitespace collapses runs of spaces within lines, trims every line
+// and keeps at most one blank line between paragraphs
+func CollapseWhitespace(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(horizontalSpace.ReplaceAllString(line, " "))
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// RewriteImageURLs passes the src and srcset URLs of the images in an HTML
+// fragment through rewrite. The rest of the markup is kept as-is.
+func RewriteImageURLs(s string, rewrite func(string) string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+
+	var builder strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			builder.Write(tokenizer.Raw())
+			continue
+		}
+
+		raw := string(tokenizer.Raw())
+		token := tokenizer.Token()
+		if token.Data != "img" && token.Data != "source" {
+			builder.WriteString(raw)
+			continue
+		}
+
+		changed := false
+		for i, attr := range token.Attr {
+			var value string
+			switch {
+			case attr.Key == "src" && token.Data == "img":
+				value = rewrite(attr.Val)
+			case attr.Key == "srcset":
+				value = rewriteSrcset(attr.Val, rewrite)
+			default:
+				continue
+			}
+			if value != attr.Val {
+				token.Attr[i].Val = value
+				changed = true
+			}
+		}
+
+		if changed {
+			builder.WriteString(token.String())
+		} else {
+			builder.WriteString(raw)
+		}
+	}
+
+	return builder.String()
+}
+
+// rewriteSrcset rewrites the URLs of a srcset attribute, keeping the width
+// and density descriptors
+func rewriteSrcset(srcset string, rewrite func(string) string) string {
+	candidates := strings.Split(srcset, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = rewrite(fields[0])
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// voidElements lists the HTML elements that have no end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// Truncate shortens s to at most maxLen runes including the ellipsis,
+// without splitting multi-byte characters
+func Truncate(s string, maxLen int, ellipsis string) string {
+	if maxLen <= 0 || utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+
+	keep := maxLen - utf8.RuneCountInString(ellipsis)
+	if keep <= 0 {
+		return string([]rune(s)[:maxLen])
+	}
+	return strings.TrimRightFunc(string([]rune(s)[:keep]), unicode.IsSpace) + ellipsis
+}
+
+// TruncateHTML shortens an HTML fragment to at most maxLen runes of text
+// including the ellipsis. Tags that are open at the cut are closed and
+// elements after it are dropped, so the result stays well-formed.
+func TruncateHTML(s string, maxLen int, ellipsis string) string {
+	if maxLen <= 0 || htmlTextLength(s) <= maxLen {
+		return s
+	}
+
+	var builder strings.Builder
+	var open []string
+	remaining := maxLen - utf8.RuneCountInString(ellipsis)
+	if remaining <= 0 {
+		// No room for the ellipsis, as in Truncate
+		remaining, ellipsis = maxLen, ""
+	}
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(s))
+
+loop:
+	for {
+		switch tokenizer.Next() {
+		case nethtml.ErrorToken:
+			break loop
+		case nethtml.TextToken:
+			text := html.UnescapeString(string(tokenizer.Raw()))
+			length := utf8.RuneCountInString(text)
+			if length > remaining {
+				cut := strings.TrimRightFunc(string([]rune(text)[:remaining]), unicode.IsSpace)
+				builder.WriteString(html.EscapeString(cut) + ellipsis)
+				break loop
+			}
+			remaining -= length
+			builder.Write(tokenizer.Raw())
+		case nethtml.StartTagToken:
+			name, _ := tokenizer.TagName()
+			if !voidElements[string(name)] {
+				open = append(open, string(name))
+			}
+			builder.Write(tokenizer.Raw())
+		case nethtml.EndTagToken:
+			name, _ := tokenizer.TagName()
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == string(name) {
+					open = open[:i]
+					break
+				}
+			}
+			builder.Write(tokenizer.Raw())
+		case nethtml.SelfClosingTagToken, nethtml.DoctypeToken:
+			builder.Write(tokenizer.Raw())
+		}
+	}
+
+	// Close the open tags, innermost first
+	for i := len(open) - 1; i >= 0; i-- {
+		builder.WriteString("</" + open[i] + ">")
+	}
+	return builder.String()
+}
+
+// htmlTextLength returns the length in runes of the text of an HTML fragment
+func htmlTextLength(s string) int {
+	length := 0
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(s))
+	for {
+		switch tokenizer.Next() {
+		case nethtml.ErrorToken:
+			return length
+		case nethtml.TextToken:
+			length += utf8.RuneCountInString(html.UnescapeString(string(tokenizer.Raw())))
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxLen   int
+		expected string
+	}{
+		{"Hello world", 20, "Hello world"},
+		{"Hello world", 11, "Hello world"},
+		{"Hello world", 7, "Hello…"},
+		{"Hello world", 0, "Hello world"},
+		{"日本語のタイトルです", 5, "日本語の…"},
+		{"Ünïcödé", 4, "Ünï…"},
+		{"👍👍👍👍", 3, "👍👍…"},
+		{"Hello", 1, "H"},
+		{"日本語", 2, "日…"},
+	}
+	for _, tt := range tests {
+		if result := Truncate(tt.input, tt.maxLen, "…"); result != tt.expected {
+			t.Errorf("Truncate(%q, %d) = %q, expected %q", tt.input, tt.maxLen, result, tt.expected)
+		}
+	}
+
+	// An ellipsis longer than the limit is left out
+	if result := Truncate("Hello world", 2, "..."); result != "He" {
+		t.Errorf("Expected no ellipsis, got %q", result)
+	}
+}
+
+func TestTruncateHTML(t *testing.T) {
+	tests := []struct {
+		input    string
+		maxLen   int
+		expected string
+	}{
+		{"<p>Short</p>", 10, "<p>Short</p>"},
+		{"<p>Hello world</p>", 7, "<p>Hello…</p>"},
+		{"<div><p>One</p><p>Two <b>three <i>four</i></b></p><p>Five</p></div>", 12, "<div><p>One</p><p>Two <b>thre…</b></p></div>"},
+		{"<p>Unclosed <b>bold text", 12, "<p>Unclosed <b>bo…</b></p>"},
+		{"<p>Line<br>break and more</p>", 10, "<p>Line<br>break…</p>"},
+		{"<p>Fish &amp; chips &amp; peas</p>", 9, "<p>Fish &amp; c…</p>"},
+		{"<p>&lt;tag&gt; is text</p>", 5, "<p>&lt;tag…</p>"},
+		{"<p>日本語のテキストです</p>", 5, "<p>日本語の…</p>"},
+		{"<p><img src=\"a.png\">Caption text</p>", 8, "<p><img src=\"a.png\">Caption…</p>"},
+		{"<p>Hello</p>", 1, "<p>H</p>"},
+	}
+	for _, tt := range tests {
+		if result := TruncateHTML(tt.input, tt.maxLen, "…"); result != tt.expected {
+			t.Errorf("TruncateHTML(%q, %d) = %q, expected %q", tt.input, tt.maxLen, result, tt.expected)
+		}
+	}
+
+	// An ellipsis longer than the limit is left out
+	if result := TruncateHTML("<p>Hello world</p>", 2, "..."); result != "<p>He</p>" {
+		t.Errorf("Expected no ellipsis, got %q", result)
+	}
+}